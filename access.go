@@ -0,0 +1,93 @@
+package unionpay
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const (
+	AccessTypeMerchant = "0" // 商户直连接入
+	AccessTypeAcquirer = "1" // 收单机构接入
+	AccessTypePlatform = "2" // 平台商户接入
+)
+
+// ErrAccessConfig 接入类型(accessType)所需的字段缺失。
+var ErrAccessConfig = errors.New("invalid access configuration")
+
+// SubMerchant 平台商户接入(accessType 为 2)时的二级商户信息。
+type SubMerchant struct {
+	Id   string // 二级商户代码(subMerId)
+	Name string // 二级商户全称(subMerName)
+	Abbr string // 二级商户简称(subMerAbbr)
+}
+
+// WithAcquirer 设置为收单机构接入(accessType 为 1)，acqInsCode 为收单机构代码。
+//
+// 设置之后所有交易的 accessType 默认为 1，并自动填充 acqInsCode。
+func WithAcquirer(acqInsCode string) OptionFunc {
+	return func(c *Client) {
+		c.accessType = AccessTypeAcquirer
+		c.acqInsCode = acqInsCode
+	}
+}
+
+// WithPlatform 设置为平台商户接入(accessType 为 2)，subMerchant 为默认的二级商户信息。
+//
+// 设置之后所有交易的 accessType 默认为 2，并自动填充 subMerId、subMerName 和 subMerAbbr；单笔交易可以通过 WithSubMerchant 指定其它二级商户。
+func WithPlatform(subMerchant SubMerchant) OptionFunc {
+	return func(c *Client) {
+		c.accessType = AccessTypePlatform
+		c.subMerchant = subMerchant
+	}
+}
+
+// WithSubMerchant 指定单笔交易的二级商户信息，用于平台商户接入(accessType 为 2)。
+func WithSubMerchant(subMerchant SubMerchant) CallOption {
+	return func(values url.Values) {
+		values.Set("subMerId", subMerchant.Id)
+		values.Set("subMerName", subMerchant.Name)
+		values.Set("subMerAbbr", subMerchant.Abbr)
+	}
+}
+
+// AccessType 返回交易默认使用的接入类型(accessType)。
+func (c *Client) AccessType() string {
+	return c.accessType
+}
+
+// applyAccess 根据接入类型填充并校验 acqInsCode、subMerId、subMerName、subMerAbbr。
+//
+// 请求参数中已经存在的字段不会被替换。
+func (c *Client) applyAccess(values url.Values) error {
+	switch accessType := values.Get("accessType"); accessType {
+	case AccessTypeAcquirer:
+		setDefault(values, "acqInsCode", c.acqInsCode)
+		return requireFields(values, accessType, "acqInsCode")
+	case AccessTypePlatform:
+		setDefault(values, "subMerId", c.subMerchant.Id)
+		setDefault(values, "subMerName", c.subMerchant.Name)
+		setDefault(values, "subMerAbbr", c.subMerchant.Abbr)
+		return requireFields(values, accessType, "subMerId", "subMerName", "subMerAbbr")
+	}
+	return nil
+}
+
+func setDefault(values url.Values, key, value string) {
+	if values.Get(key) == "" && value != "" {
+		values.Set(key, value)
+	}
+}
+
+func requireFields(values url.Values, accessType string, keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if values.Get(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: accessType %s requires %v", ErrAccessConfig, accessType, missing)
+	}
+	return nil
+}
@@ -3,16 +3,17 @@ package unionpay
 import (
 	"context"
 	"net/url"
-	"time"
 )
 
 // CreateAccountPayment 无跳转支付-消费接口。
 //
+// 应答码(respCode)表示失败时依然返回结果和 nil，需要通过结果中的 Error 判断交易是否成功。
+//
 // 文档地址：https://open.unionpay.com/tjweb/acproduct/APIList?acpAPIId=814&apiservId=449&version=V2.2&bussType=0
 //
 // 文档地址：https://open.unionpay.com/upload/download/%E6%97%A0%E8%B7%B3%E8%BD%AC%E6%94%AF%E4%BB%98%E4%BA%A7%E5%93%81%E6%8E%A5%E5%8F%A3%E8%A7%84%E8%8C%83V2.0.pdf
 //
-// orderId：商户消费订单号。
+// orderId：商户消费订单号，8-32 位字母和数字，为空时自动生成。
 //
 // amount：交易金额，单位分，不要带小数点。
 //
@@ -20,15 +21,34 @@ import (
 //
 // accNo：账号、卡号。
 func (c *Client) CreateAccountPayment(ctx context.Context, orderId, amount, backURL, accNo string, customer *Customer, opts ...CallOption) (*AccountPayment, error) {
+	var req = &AccountPaymentRequest{}
+	req.OrderId = orderId
+	req.TxnAmt = amount
+	req.BackURL = backURL
+	req.AccNo = accNo
+	req.Customer = customer
+	return c.CreateAccountPaymentWith(ctx, req, opts...)
+}
+
+// CreateAccountPaymentWith 无跳转支付-消费接口，参考 CreateAccountPayment。
+//
+// req.OrderId 为空时会通过 NewOrderId 生成并写回 req。
+//
+// req 中非空的字段会替换 WithPayload() 设置的同名参数，之后会校验最终的请求参数（包括 WithPayload() 设置的参数），校验失败时返回 ValidationError。
+func (c *Client) CreateAccountPaymentWith(ctx context.Context, req *AccountPaymentRequest, opts ...CallOption) (*AccountPayment, error) {
+	if req.OrderId == "" {
+		req.OrderId = c.NewOrderId()
+	}
+
 	var values = url.Values{}
 	// 此处的参数可被 WithPayload() 替换
-	values.Set("accessType", "0")
+	values.Set("accessType", c.accessType)
 	values.Set("currencyCode", "156") // 交易币种 156 - 人民币
 	values.Set("channelType", "07")   // 渠道类型，这个字段区分B2C网关支付和手机wap支付；07 - PC,平板  08 - 手机
 	values.Set("bizType", "000301")   // 业务类型，000301 - 认证支付2.0
 	values.Set("txnType", "01")
 	values.Set("txnSubType", "01") // 01：自助消费，通过地址的方式区分前台消费和后台消费（含无跳转支付） 03：分期付款
-	values.Set("txnTime", time.Now().Format("20060102150405"))
+	values.Set("txnTime", c.txnTime())
 	values.Set("accType", "01") // 账号类型 后台类交易且卡号上送； 跨行收单且收单机构收集银行卡信息时上送 01：银行卡 02：存折 03：IC卡 默认取值：01 取值“03”表示以IC终端发起的IC卡交易，IC作为普通银行卡进行支付时，此域填写为“01”
 	for _, opt := range opts {
 		if opt != nil {
@@ -36,18 +56,27 @@ func (c *Client) CreateAccountPayment(ctx context.Context, orderId, amount, back
 		}
 	}
 
-	values.Set("orderId", orderId)
-	values.Set("txnAmt", amount)
-	values.Set("backUrl", backURL)
+	if err := EncodeRequest(values, req); err != nil {
+		return nil, err
+	}
+	if err := validateRequest(values, req); err != nil {
+		return nil, err
+	}
+	if err := encodeAccSplitData(values, req.AccSplitData); err != nil {
+		return nil, err
+	}
+	if err := c.applyPayTimeout(values, req.PayTimeout); err != nil {
+		return nil, err
+	}
 
 	values.Set("encryptCertId", c.EncryptCertId())
-	acc, err := c.Encrypt(accNo)
+	acc, err := c.Encrypt(req.AccNo)
 	if err != nil {
 		return nil, err
 	}
 	values.Set("accNo", acc)
 
-	customerInfo, err := c.EncryptCustomer(customer, accNo)
+	customerInfo, err := c.EncryptCustomer(req.Customer, req.AccNo)
 	if err != nil {
 		return nil, err
 	}
@@ -67,6 +96,8 @@ func (c *Client) CreateAccountPayment(ctx context.Context, orderId, amount, back
 
 // ReverseAccountPayment 无跳转支付-冲正（退货）。
 //
+// 应答码(respCode)表示失败时依然返回结果和 nil，需要通过结果中的 Error 判断交易是否成功。
+//
 // 文档地址：https://open.unionpay.com/tjweb/acproduct/APIList?acpAPIId=815&apiservId=449&version=V2.2&bussType=0
 //
 // orderId：商户订单号。
@@ -75,9 +106,19 @@ func (c *Client) CreateAccountPayment(ctx context.Context, orderId, amount, back
 //
 // 冲正必须与原始消费在同一天（准确讲是昨日23:00至本日23:00之间）。 冲正交易，仅用于超时无应答等异常场景，只有发生支付系统超时或者支付结果未知时可调用冲正，其他正常支付的订单如果需要实现相通功能，请调用消费撤销或者退货。
 func (c *Client) ReverseAccountPayment(ctx context.Context, orderId, txnTime string, opts ...CallOption) (*Reverse, error) {
+	var req = &ReverseRequest{}
+	req.OrderId = orderId
+	req.TxnTime = txnTime
+	return c.ReverseAccountPaymentWith(ctx, req, opts...)
+}
+
+// ReverseAccountPaymentWith 无跳转支付-冲正（退货），参考 ReverseAccountPayment。
+//
+// req 中非空的字段会替换 WithPayload() 设置的同名参数，之后会校验最终的请求参数（包括 WithPayload() 设置的参数），校验失败时返回 ValidationError。
+func (c *Client) ReverseAccountPaymentWith(ctx context.Context, req *ReverseRequest, opts ...CallOption) (*Reverse, error) {
 	var values = url.Values{}
 	// 此处的参数可被 WithPayload() 替换
-	values.Set("accessType", "0")
+	values.Set("accessType", c.accessType)
 	values.Set("currencyCode", "156") // 交易币种 156 - 人民币
 	values.Set("channelType", "07")   // 渠道类型，这个字段区分B2C网关支付和手机wap支付；07 - PC,平板  08 - 手机
 	values.Set("bizType", "000000")   // 业务类型
@@ -90,8 +131,12 @@ func (c *Client) ReverseAccountPayment(ctx context.Context, orderId, txnTime str
 		}
 	}
 
-	values.Set("orderId", orderId)
-	values.Set("txnTime", txnTime)
+	if err := EncodeRequest(values, req); err != nil {
+		return nil, err
+	}
+	if err := validateRequest(values, req); err != nil {
+		return nil, err
+	}
 
 	var rValues, err = c.Request(ctx, kQueryTrans, values)
 	if err != nil {
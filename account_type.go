@@ -15,6 +15,9 @@ type AccountPayment struct {
 	TxnType      string `query:"txnType"`      // 交易类型
 	TxnSubType   string `query:"txnSubType"`   // 交易子类
 	AccessType   string `query:"accessType"`   // 接入类型
+	SubMerId     string `query:"subMerId"`     // 二级商户代码
+	SubMerName   string `query:"subMerName"`   // 二级商户全称
+	SubMerAbbr   string `query:"subMerAbbr"`   // 二级商户简称
 	ReqReserved  string `query:"reqReserved"`  // 请求方保留域
 	MerId        string `query:"merId"`        // 商户代码
 	OrderId      string `query:"orderId"`      // 商户订单号
@@ -29,6 +32,10 @@ type Reverse struct {
 	TxnType     string `query:"txnType"`     // 交易类型
 	TxnSubType  string `query:"txnSubType"`  // 交易子类
 	AccessType  string `query:"accessType"`  // 接入类型
+	AcqInsCode  string `query:"acqInsCode"`  // 收单机构代码
+	SubMerId    string `query:"subMerId"`    // 二级商户代码
+	SubMerName  string `query:"subMerName"`  // 二级商户全称
+	SubMerAbbr  string `query:"subMerAbbr"`  // 二级商户简称
 	ReqReserved string `query:"reqReserved"` // 请求方保留域
 	MerId       string `query:"merId"`       // 商户代码
 	OrderId     string `query:"orderId"`     // 商户订单号
@@ -0,0 +1,172 @@
+package unionpay
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type CancelMethod string
+
+const (
+	CancelMethodRevoke CancelMethod = "revoke" // 消费撤销
+	CancelMethodRefund CancelMethod = "refund" // 退货
+)
+
+// kRefundedParam 用于在 Cancel 的 CallOption 中传递 WithRefunded 设置的金额，不会提交给银联。
+const kRefundedParam = "_refunded"
+
+// WithRefunded 设置原消费交易已经发起过的退货金额（包括处理中的退货），单位分，仅适用于 Cancel。
+func WithRefunded(amount int64) CallOption {
+	return func(values url.Values) {
+		values.Set(kRefundedParam, strconv.FormatInt(amount, 10))
+	}
+}
+
+// Cancellation 取消交易（消费撤销或者退货）的结果。
+type Cancellation struct {
+	Error
+	Method      CancelMethod // 实际使用的方式
+	OrderId     string       // 商户消费撤销或者退货订单号
+	TxnTime     string       // 订单发送时间
+	TxnAmt      string       // 交易金额
+	QueryId     string       // 银联交易流水号
+	Transaction *Transaction // 原消费交易的查询结果
+	Revoke      *Revoke      // Method 为 CancelMethodRevoke 时有值
+	Refund      *Refund      // Method 为 CancelMethodRefund 时有值
+	RevokeError error        // 消费撤销失败之后改为退货时，消费撤销的错误信息
+}
+
+// Cancel 取消交易，自动选择消费撤销(Revoke)或者退货(Refund)，退还原消费交易剩余的全部金额。
+//
+// 会先通过交易状态查询接口(GetTransaction)查询原消费交易，原交易必须为成功状态（包括超时扣款，即 TransactionStatePaidAfterTimeout）。
+//
+// 如果当前时间与原交易在同一清算日内（前一日 23:00 至当日 23:00，北京时间），则发起消费撤销；否则发起退货。
+//
+// 消费撤销被银联拒绝（如发卡行不支持）时，会通过 NewOrderId 生成新的订单号改为发起退货，此时 RevokeError 为消费撤销的错误信息，Cancellation.OrderId 为退货订单号。
+//
+// 原消费交易已经发生过（部分）退货时，需要通过 WithRefunded 设置已退货金额，此时不能再发起消费撤销，只会对剩余金额发起退货；剩余金额不大于 0 时返回 ErrRefundExceeded，不会请求银联。
+//
+// 应答码(respCode)表示失败时依然返回结果和 nil，需要通过结果中的 Error 判断交易是否成功。
+//
+// origOrderId：原消费交易的商户订单号。
+//
+// origTxnTime：原消费交易的订单发送时间。
+//
+// orderId：商户消费撤销或者退货订单号，为空时自动生成。
+//
+// backURL：后台通知地址。
+func (c *Client) Cancel(ctx context.Context, origOrderId, origTxnTime, orderId, backURL string, opts ...CallOption) (*Cancellation, error) {
+	refunded, opts, err := cancelRefunded(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := c.GetTransaction(ctx, origOrderId, origTxnTime)
+	if err != nil {
+		return nil, err
+	}
+	if state := transaction.State(); state != TransactionStateSucceeded && state != TransactionStatePaidAfterTimeout {
+		return nil, fmt.Errorf("%w: original transaction is %s", ErrInvalidOrderTransition, state)
+	}
+
+	var cancellation = &Cancellation{}
+	cancellation.Transaction = transaction
+
+	amount, err := strconv.ParseInt(transaction.TxnAmt, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid txnAmt %q of original transaction: %w", transaction.TxnAmt, err)
+	}
+	if amount -= refunded; amount <= 0 {
+		return nil, fmt.Errorf("%w: original transaction has been fully refunded", ErrRefundExceeded)
+	}
+
+	// 发生过退货的交易不能再发起消费撤销
+	revocable, err := isRevocable(transaction, c.now())
+	if err != nil {
+		return nil, err
+	}
+	revocable = revocable && refunded == 0
+
+	if revocable {
+		revoke, err := c.Revoke(ctx, transaction.QueryId, orderId, transaction.TxnAmt, backURL, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		if !revoke.IsFinalFailure() || revoke.IsDuplicate() {
+			cancellation.Method = CancelMethodRevoke
+			cancellation.Revoke = revoke
+			cancellation.Error = revoke.Error
+			cancellation.OrderId = revoke.OrderId
+			cancellation.TxnTime = revoke.TxnTime
+			cancellation.TxnAmt = revoke.TxnAmt
+			cancellation.QueryId = revoke.QueryId
+			return cancellation, nil
+		}
+		cancellation.RevokeError = revoke.Error
+		// 同一商户同一天内订单号不能重复，退货需要使用新的订单号
+		orderId = c.NewOrderId()
+	}
+
+	refund, err := c.Refund(ctx, transaction.QueryId, orderId, strconv.FormatInt(amount, 10), backURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	cancellation.Method = CancelMethodRefund
+	cancellation.Refund = refund
+	cancellation.Error = refund.Error
+	cancellation.OrderId = refund.OrderId
+	cancellation.TxnTime = refund.TxnTime
+	cancellation.TxnAmt = refund.TxnAmt
+	cancellation.QueryId = refund.QueryId
+	return cancellation, nil
+}
+
+// cancelRefunded 返回 WithRefunded 设置的金额，以及会在提交之前移除该金额的 CallOption。
+func cancelRefunded(opts []CallOption) (int64, []CallOption, error) {
+	var values = url.Values{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(values)
+		}
+	}
+
+	var refunded int64
+	if s := values.Get(kRefundedParam); s != "" {
+		var err error
+		if refunded, err = strconv.ParseInt(s, 10, 64); err != nil || refunded < 0 {
+			return 0, nil, fmt.Errorf("invalid refunded amount %s", s)
+		}
+	}
+	return refunded, append(opts[:len(opts):len(opts)], func(values url.Values) {
+		values.Del(kRefundedParam)
+	}), nil
+}
+
+// isRevocable 判断原消费交易当前是否可以发起消费撤销。
+//
+// 消费撤销只能在原交易所在的清算日内发起，清算日以当日 23:00（北京时间）为界。
+func isRevocable(transaction *Transaction, now time.Time) (bool, error) {
+	cutoff, err := settleCutoff(transaction.TxnTime)
+	if err != nil {
+		return false, err
+	}
+	if !now.Before(cutoff) {
+		return false, nil
+	}
+
+	// 清算日期(settleDate)的格式为 MMDD，需要与当前所在的清算日一致
+	if transaction.SettleDate != "" {
+		current, err := settleCutoff(now.In(kBeijing).Format(kTimeFormat))
+		if err != nil {
+			return false, err
+		}
+		if transaction.SettleDate != current.Format("0102") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,113 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestClient_Cancel(t *testing.T) {
+	var pki = newTestPKI(t)
+	var txnTime = "20261019120000"
+	var sent, _ = ParseTxnTime(txnTime)
+
+	var tests = []struct {
+		name       string
+		now        time.Time
+		refunded   int64
+		revokeCode string // 消费撤销的应答码，为空时表示不应发起消费撤销
+		method     CancelMethod
+		txnAmt     string // 实际发起的消费撤销或者退货金额
+		err        error
+	}{
+		{"same settle day", sent.Add(time.Hour), 0, "00", CancelMethodRevoke, "1000", nil},
+		{"just before cutoff", time.Date(2026, 10, 19, 22, 59, 59, 0, kBeijing), 0, "00", CancelMethodRevoke, "1000", nil},
+		{"at cutoff", time.Date(2026, 10, 19, 23, 0, 0, 0, kBeijing), 0, "", CancelMethodRefund, "1000", nil},
+		{"next day", sent.AddDate(0, 0, 1), 0, "", CancelMethodRefund, "1000", nil},
+		{"revoke declined", sent.Add(time.Hour), 0, "30", CancelMethodRefund, "1000", nil},
+		{"partially refunded", sent.Add(time.Hour), 300, "", CancelMethodRefund, "700", nil},
+		{"partially refunded next day", sent.AddDate(0, 0, 1), 999, "", CancelMethodRefund, "1", nil},
+		{"fully refunded", sent.Add(time.Hour), 1000, "", "", "", ErrRefundExceeded},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var revoked, refunded string
+			var revokeOrderId, refundOrderId string
+			var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+				if _, ok := values[kRefundedParam]; ok {
+					t.Errorf("unexpected parameter %s", kRefundedParam)
+				}
+				if api == kQueryTrans {
+					var rValues = testResponse(values, "00")
+					rValues.Set("txnType", kTxnTypeConsume)
+					rValues.Set("txnTime", txnTime)
+					rValues.Set("txnAmt", "1000")
+					rValues.Set("queryId", "123456789012345678901")
+					rValues.Set("settleDate", "1019")
+					rValues.Set("origRespCode", "00")
+					return rValues
+				}
+
+				switch values.Get("txnType") {
+				case kTxnTypeRevoke:
+					revoked = values.Get("txnAmt")
+					revokeOrderId = values.Get("orderId")
+					if test.revokeCode == "" {
+						t.Errorf("unexpected revoke of %s", revoked)
+					}
+					return testResponse(values, test.revokeCode)
+				case kTxnTypeRefund:
+					refunded = values.Get("txnAmt")
+					refundOrderId = values.Get("orderId")
+					return testResponse(values, "00")
+				}
+				t.Errorf("unexpected txnType %s", values.Get("txnType"))
+				return testResponse(values, "12")
+			})
+			var now = test.now
+			var client = newTestClient(t, pki, WithGateway(server.URL), WithClock(ClockFunc(func() time.Time { return now })))
+
+			cancellation, err := client.Cancel(context.Background(), "20261019120000abcdef0001", txnTime, "20261019120000abcdef0002", "https://example.com/notify", WithRefunded(test.refunded))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Cancel() error = %v, want %v", err, test.err)
+			}
+			if test.err != nil {
+				if revoked != "" || refunded != "" {
+					t.Fatalf("Cancel() sent a request: revoke %q, refund %q", revoked, refunded)
+				}
+				return
+			}
+
+			if cancellation.Method != test.method {
+				t.Fatalf("Method = %s, want %s", cancellation.Method, test.method)
+			}
+			if cancellation.TxnAmt != test.txnAmt {
+				t.Fatalf("TxnAmt = %s, want %s", cancellation.TxnAmt, test.txnAmt)
+			}
+			if (cancellation.RevokeError != nil) != (test.revokeCode != "" && test.method == CancelMethodRefund) {
+				t.Fatalf("RevokeError = %v", cancellation.RevokeError)
+			}
+
+			// 消费撤销失败之后的退货使用新的订单号
+			var orderId = "20261019120000abcdef0002"
+			if cancellation.RevokeError != nil {
+				if refundOrderId == "" || refundOrderId == revokeOrderId {
+					t.Fatalf("refund orderId = %q, revoke orderId = %q, want a new orderId", refundOrderId, revokeOrderId)
+				}
+				orderId = refundOrderId
+			}
+			if cancellation.OrderId != orderId {
+				t.Fatalf("OrderId = %s, want %s", cancellation.OrderId, orderId)
+			}
+		})
+	}
+
+	var client = newTestClient(t, pki)
+	if _, err := client.Cancel(context.Background(), "20261019120000abcdef0001", txnTime, "", "https://example.com/notify", WithRefunded(-1)); err == nil {
+		t.Fatal("Cancel() accepted a negative refunded amount")
+	}
+}
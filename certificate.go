@@ -0,0 +1,200 @@
+package unionpay
+
+import (
+	"context"
+	"crypto/x509"
+	"sort"
+	"sync"
+	"time"
+)
+
+type CertificateKind string
+
+const (
+	CertificateKindSign         CertificateKind = "sign"         // 商户签名证书(PFX)
+	CertificateKindRoot         CertificateKind = "root"         // 银联根证书
+	CertificateKindIntermediate CertificateKind = "intermediate" // 银联中间证书
+	CertificateKindEncrypt      CertificateKind = "encrypt"      // 银联敏感信息加密证书
+	CertificateKindVerify       CertificateKind = "verify"       // 银联签名公钥证书(signPubKeyCert)，验签时缓存
+)
+
+// CertificateInfo 证书信息。
+type CertificateInfo struct {
+	Kind          CertificateKind
+	Subject       string
+	CertId        string // 证书序列号，即各接口中的 certId、encryptCertId
+	NotBefore     time.Time
+	NotAfter      time.Time
+	DaysRemaining int // 距离过期的天数，已过期时为负数
+}
+
+func newCertificateInfo(kind CertificateKind, cert *x509.Certificate, now time.Time) CertificateInfo {
+	var info = CertificateInfo{}
+	info.Kind = kind
+	info.Subject = cert.Subject.String()
+	info.CertId = cert.SerialNumber.String()
+	info.NotBefore = cert.NotBefore
+	info.NotAfter = cert.NotAfter
+	info.DaysRemaining = daysRemaining(cert.NotAfter, now)
+	return info
+}
+
+// daysRemaining 向下取整，已过期不足一天时返回 -1，而不是 0。
+func daysRemaining(notAfter, now time.Time) int {
+	var remaining = notAfter.Sub(now)
+	var days = remaining / (24 * time.Hour)
+	if remaining < 0 && remaining%(24*time.Hour) != 0 {
+		days--
+	}
+	return int(days)
+}
+
+// Certificates 返回当前已加载的所有证书的信息，包括商户签名证书、根证书、中间证书、敏感信息加密证书以及验签时缓存的银联签名公钥证书。
+func (c *Client) Certificates() []CertificateInfo {
+	return c.certificates(c.now())
+}
+
+func (c *Client) certificates(now time.Time) []CertificateInfo {
+	var infos []CertificateInfo
+
+	if c.signCert != nil {
+		infos = append(infos, newCertificateInfo(CertificateKindSign, c.signCert, now))
+	}
+	if c.rootCert != nil {
+		infos = append(infos, newCertificateInfo(CertificateKindRoot, c.rootCert, now))
+	}
+	if c.interCert != nil {
+		infos = append(infos, newCertificateInfo(CertificateKindIntermediate, c.interCert, now))
+	}
+
+	c.encryptMu.RLock()
+	var encryptCert = c.encryptCert
+	c.encryptMu.RUnlock()
+	if encryptCert != nil {
+		infos = append(infos, newCertificateInfo(CertificateKindEncrypt, encryptCert, now))
+	}
+
+	for _, cert := range c.verifiers.certificates() {
+		infos = append(infos, newCertificateInfo(CertificateKindVerify, cert, now))
+	}
+
+	return infos
+}
+
+// CertificateWarning 证书即将过期（或已经过期）的告警信息。
+type CertificateWarning struct {
+	CertificateInfo
+	Threshold time.Duration // 触发本次告警的阈值
+	Expired   bool          // 证书是否已经过期
+}
+
+// CertificateMonitor 用于检查证书有效期，在证书距离过期时间小于设定的阈值时发出告警。
+//
+// 同一证书的同一阈值只会告警一次，证书过期时会再告警一次。
+type CertificateMonitor struct {
+	client     *Client
+	thresholds []time.Duration
+	handler    func(warning CertificateWarning)
+
+	mu     sync.Mutex
+	warned map[string]time.Duration
+}
+
+// NewCertificateMonitor 创建证书有效期检查器。
+//
+// thresholds - 告警阈值，如 30 * 24 * time.Hour 表示在证书过期前 30 天发出告警，为空时使用 30 天、7 天和 1 天。
+//
+// handler - 告警回调函数。
+func NewCertificateMonitor(client *Client, thresholds []time.Duration, handler func(warning CertificateWarning)) *CertificateMonitor {
+	if len(thresholds) == 0 {
+		thresholds = []time.Duration{30 * 24 * time.Hour, 7 * 24 * time.Hour, 24 * time.Hour}
+	}
+
+	var nMonitor = &CertificateMonitor{}
+	nMonitor.client = client
+	nMonitor.thresholds = append([]time.Duration(nil), thresholds...)
+	sort.Slice(nMonitor.thresholds, func(i, j int) bool {
+		return nMonitor.thresholds[i] < nMonitor.thresholds[j]
+	})
+	nMonitor.handler = handler
+	nMonitor.warned = make(map[string]time.Duration)
+	return nMonitor
+}
+
+// Check 检查一次所有证书的有效期，返回本次新产生的告警信息，并对每一条告警信息调用 handler。
+func (m *CertificateMonitor) Check() []CertificateWarning {
+	return m.check(m.client.now())
+}
+
+func (m *CertificateMonitor) check(now time.Time) []CertificateWarning {
+	var warnings []CertificateWarning
+
+	m.mu.Lock()
+	var loaded = make(map[string]struct{}, len(m.warned))
+	for _, info := range m.client.certificates(now) {
+		var key = string(info.Kind) + "/" + info.CertId
+		loaded[key] = struct{}{}
+
+		var remaining = info.NotAfter.Sub(now)
+
+		var warning = CertificateWarning{CertificateInfo: info}
+		if remaining <= 0 {
+			warning.Expired = true
+		} else {
+			var matched = false
+			for _, threshold := range m.thresholds {
+				if remaining <= threshold {
+					warning.Threshold = threshold
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+
+		// 只有在跨越了更小的阈值时才再次告警，过期的阈值为 0
+		if last, ok := m.warned[key]; ok && last <= warning.Threshold {
+			continue
+		}
+		m.warned[key] = warning.Threshold
+		warnings = append(warnings, warning)
+	}
+
+	// 证书被替换或者移出验签缓存之后，不再保留其告警记录
+	for key := range m.warned {
+		if _, ok := loaded[key]; !ok {
+			delete(m.warned, key)
+		}
+	}
+	m.mu.Unlock()
+
+	if m.handler != nil {
+		for _, warning := range warnings {
+			m.handler(warning)
+		}
+	}
+	return warnings
+}
+
+// Run 立即检查一次证书有效期，之后每隔 interval 检查一次，直到 ctx 结束，interval 小于等于 0 时为 1 小时。
+func (m *CertificateMonitor) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	m.Check()
+
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
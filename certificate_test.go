@@ -0,0 +1,62 @@
+package unionpay
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDaysRemaining(t *testing.T) {
+	var notAfter = time.Date(2026, 10, 20, 0, 0, 0, 0, kBeijing)
+
+	var tests = []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{"two days left", notAfter.Add(-48 * time.Hour), 2},
+		{"almost two days left", notAfter.Add(-47 * time.Hour), 1},
+		{"less than a day left", notAfter.Add(-time.Hour), 0},
+		{"expires now", notAfter, 0},
+		{"expired an hour ago", notAfter.Add(time.Hour), -1},
+		{"expired exactly a day ago", notAfter.Add(24 * time.Hour), -1},
+		{"expired a day and an hour ago", notAfter.Add(25 * time.Hour), -2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := daysRemaining(notAfter, test.now); got != test.want {
+				t.Fatalf("daysRemaining() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCertificateMonitor_ForgetsReplacedCertificate(t *testing.T) {
+	var pki = newTestPKI(t)
+	var client = newTestClient(t, pki)
+	var monitor = NewCertificateMonitor(client, nil, nil)
+
+	var now = time.Now()
+	var old, _ = pki.issue(t, now.Add(48*time.Hour))
+	if err := client.setEncryptCert(old); err != nil {
+		t.Fatal(err)
+	}
+	var oldKey = string(CertificateKindEncrypt) + "/" + old.SerialNumber.String()
+
+	monitor.check(now)
+	if _, ok := monitor.warned[oldKey]; !ok {
+		t.Fatalf("no warning recorded for encrypt cert %s", old.SerialNumber)
+	}
+
+	var current, _ = pki.issue(t, now.Add(48*time.Hour))
+	if err := client.setEncryptCert(current); err != nil {
+		t.Fatal(err)
+	}
+	monitor.check(now)
+	if _, ok := monitor.warned[oldKey]; ok {
+		t.Fatalf("warning for replaced encrypt cert %s is still recorded", old.SerialNumber)
+	}
+	if _, ok := monitor.warned[string(CertificateKindEncrypt)+"/"+current.SerialNumber.String()]; !ok {
+		t.Fatalf("no warning recorded for encrypt cert %s", current.SerialNumber)
+	}
+}
@@ -0,0 +1,350 @@
+package unionpay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/smartwalle/ncrypto"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	EnvironmentSandbox    = "sandbox"    // 沙箱环境
+	EnvironmentProduction = "production" // 生产环境
+)
+
+// Duration 用于在配置文件中使用 30s、1m 等格式表示时间。
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Config 客户端配置，可以通过 LoadConfig 从 YAML 或者 JSON 文件加载，并使用 UNIONPAY_ 开头的环境变量覆盖。
+//
+// 可以通过 NewWithConfig 创建 Client，也可以通过 Registry.Add 在 Registry 中创建 Client。
+//
+// 各字段对应的环境变量见字段注释。
+type Config struct {
+	MerchantId  string `json:"merchant_id" yaml:"merchant_id"`   // 商户号，UNIONPAY_MERCHANT_ID
+	PFX         []byte `json:"-" yaml:"-"`                       // 商户私钥证书，与 PFXFile 二选一，设置之后忽略 PFXFile
+	PFXFile     string `json:"pfx_file" yaml:"pfx_file"`         // 商户私钥证书文件，UNIONPAY_PFX_FILE
+	PFXPassword string `json:"pfx_password" yaml:"pfx_password"` // 商户私钥证书密码，UNIONPAY_PFX_PASSWORD
+	Environment string `json:"environment" yaml:"environment"`   // 环境，sandbox 或者 production，默认为 sandbox，UNIONPAY_ENVIRONMENT
+	GatewayURL  string `json:"gateway_url" yaml:"gateway_url"`   // 网关地址，为空时根据 Environment 选择银联网关，UNIONPAY_GATEWAY_URL
+
+	RootCert             string `json:"root_cert" yaml:"root_cert"`                           // 银联根证书(PEM)，与 RootCertFile 二选一，设置之后忽略 RootCertFile，UNIONPAY_ROOT_CERT
+	RootCertFile         string `json:"root_cert_file" yaml:"root_cert_file"`                 // 银联根证书文件，UNIONPAY_ROOT_CERT_FILE
+	IntermediateCert     string `json:"intermediate_cert" yaml:"intermediate_cert"`           // 银联中间证书(PEM)，与 IntermediateCertFile 二选一，设置之后忽略 IntermediateCertFile，UNIONPAY_INTERMEDIATE_CERT
+	IntermediateCertFile string `json:"intermediate_cert_file" yaml:"intermediate_cert_file"` // 银联中间证书文件，UNIONPAY_INTERMEDIATE_CERT_FILE
+	EncryptCertFile      string `json:"encrypt_cert_file" yaml:"encrypt_cert_file"`           // 敏感信息加密证书缓存文件，参考 WithEncryptKeyFile，UNIONPAY_ENCRYPT_CERT_FILE
+
+	Timeout Duration `json:"timeout" yaml:"timeout"` // 请求银联接口的超时时间，为 0 时不超时，UNIONPAY_TIMEOUT
+
+	AccessType string `json:"access_type" yaml:"access_type"`   // 接入类型，默认为 0，UNIONPAY_ACCESS_TYPE
+	AcqInsCode string `json:"acq_ins_code" yaml:"acq_ins_code"` // 收单机构代码，AccessType 为 1 时必填，UNIONPAY_ACQ_INS_CODE
+	SubMerId   string `json:"sub_mer_id" yaml:"sub_mer_id"`     // 二级商户代码，AccessType 为 2 时必填，UNIONPAY_SUB_MER_ID
+	SubMerName string `json:"sub_mer_name" yaml:"sub_mer_name"` // 二级商户全称，AccessType 为 2 时必填，UNIONPAY_SUB_MER_NAME
+	SubMerAbbr string `json:"sub_mer_abbr" yaml:"sub_mer_abbr"` // 二级商户简称，AccessType 为 2 时必填，UNIONPAY_SUB_MER_ABBR
+}
+
+// ConfigError 配置校验失败，Problems 包含所有的问题。
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid unionpay config: " + strings.Join(e.Problems, "; ")
+}
+
+// LoadConfig 从文件加载配置，根据扩展名识别格式（.yaml、.yml、.json），然后使用环境变量覆盖，最后进行校验。
+//
+// filename 为空时只从环境变量加载。
+//
+// 读取或者解析文件失败、环境变量格式错误时依然会继续加载和校验，所有的错误通过 errors.Join 一起返回。
+func LoadConfig(filename string) (*Config, error) {
+	var config = &Config{}
+	var errs []error
+	if filename != "" {
+		if err := config.decodeFile(filename); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filename, err))
+		}
+	}
+	if err := config.ApplyEnv(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := config.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return config, nil
+}
+
+func (c *Config) decodeFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(data, c)
+	case ".json":
+		return json.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("unsupported config file extension %q", ext)
+	}
+}
+
+// ApplyEnv 使用 UNIONPAY_ 开头的环境变量覆盖配置，未设置的环境变量不会修改对应的字段。
+func (c *Config) ApplyEnv() error {
+	for name, field := range map[string]*string{
+		"UNIONPAY_MERCHANT_ID":            &c.MerchantId,
+		"UNIONPAY_PFX_FILE":               &c.PFXFile,
+		"UNIONPAY_PFX_PASSWORD":           &c.PFXPassword,
+		"UNIONPAY_ENVIRONMENT":            &c.Environment,
+		"UNIONPAY_GATEWAY_URL":            &c.GatewayURL,
+		"UNIONPAY_ROOT_CERT":              &c.RootCert,
+		"UNIONPAY_ROOT_CERT_FILE":         &c.RootCertFile,
+		"UNIONPAY_INTERMEDIATE_CERT":      &c.IntermediateCert,
+		"UNIONPAY_INTERMEDIATE_CERT_FILE": &c.IntermediateCertFile,
+		"UNIONPAY_ENCRYPT_CERT_FILE":      &c.EncryptCertFile,
+		"UNIONPAY_ACCESS_TYPE":            &c.AccessType,
+		"UNIONPAY_ACQ_INS_CODE":           &c.AcqInsCode,
+		"UNIONPAY_SUB_MER_ID":             &c.SubMerId,
+		"UNIONPAY_SUB_MER_NAME":           &c.SubMerName,
+		"UNIONPAY_SUB_MER_ABBR":           &c.SubMerAbbr,
+	} {
+		if value, ok := os.LookupEnv(name); ok {
+			*field = value
+		}
+	}
+
+	if value, ok := os.LookupEnv("UNIONPAY_TIMEOUT"); ok {
+		if err := c.Timeout.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("UNIONPAY_TIMEOUT: %w", err)
+		}
+	}
+	return nil
+}
+
+// IsProduction 是否为生产环境。
+func (c *Config) IsProduction() bool {
+	return c.Environment == EnvironmentProduction
+}
+
+// Validate 校验配置，一次返回所有的问题。
+//
+// 除了必填字段和各文件是否存在之外，还会使用 PFXPassword 解析商户私钥证书，并解析银联根证书、中间证书以及已经存在的敏感信息加密证书缓存文件；
+// 字段问题通过 ConfigError 返回，证书解析失败的错误与其通过 errors.Join 一起返回。
+func (c *Config) Validate() error {
+	var problems []string
+	var add = func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	// 证书内容(inline)与证书文件二选一，设置了证书内容时不检查文件
+	var checkFile = func(name string, inline bool, filename string) {
+		if inline {
+			return
+		}
+		if filename == "" {
+			add("%s is required", name)
+			return
+		}
+		if _, err := os.Stat(filename); err != nil {
+			add("%s: %v", name, err)
+		}
+	}
+
+	if c.MerchantId == "" {
+		add("merchant_id is required")
+	}
+	checkFile("pfx_file", len(c.PFX) > 0, c.PFXFile)
+	checkFile("root_cert_file", c.RootCert != "", c.RootCertFile)
+	checkFile("intermediate_cert_file", c.IntermediateCert != "", c.IntermediateCertFile)
+
+	if c.EncryptCertFile != "" {
+		if _, err := os.Stat(filepath.Dir(c.EncryptCertFile)); err != nil {
+			add("encrypt_cert_file: %v", err)
+		}
+	}
+
+	switch c.Environment {
+	case "", EnvironmentSandbox, EnvironmentProduction:
+	default:
+		add("environment must be %q or %q, got %q", EnvironmentSandbox, EnvironmentProduction, c.Environment)
+	}
+
+	if c.GatewayURL != "" {
+		if u, err := url.Parse(c.GatewayURL); err != nil {
+			add("gateway_url: %v", err)
+		} else if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			add("gateway_url must be an absolute http(s) URL, got %q", c.GatewayURL)
+		}
+	}
+
+	if c.Timeout < 0 {
+		add("timeout must not be negative")
+	}
+
+	switch c.AccessType {
+	case "", AccessTypeMerchant:
+	case AccessTypeAcquirer:
+		if c.AcqInsCode == "" {
+			add("acq_ins_code is required when access_type is 1")
+		}
+	case AccessTypePlatform:
+		if c.SubMerId == "" || c.SubMerName == "" || c.SubMerAbbr == "" {
+			add("sub_mer_id, sub_mer_name and sub_mer_abbr are required when access_type is 2")
+		}
+	default:
+		add("access_type must be 0, 1 or 2, got %q", c.AccessType)
+	}
+
+	var errs []error
+	if len(problems) > 0 {
+		errs = append(errs, &ConfigError{Problems: problems})
+	}
+	errs = append(errs, c.validateCerts()...)
+	return errors.Join(errs...)
+}
+
+// validateCerts 解析配置中的各证书，返回所有解析失败的错误；无法读取的文件已经在 Validate 中检查，这里会跳过。
+func (c *Config) validateCerts() []error {
+	var errs []error
+
+	if pfx, ok := readConfigFile(string(c.PFX), c.PFXFile); ok {
+		if _, _, err := decodePFX([]byte(pfx), c.PFXPassword); err != nil {
+			errs = append(errs, fmt.Errorf("pfx: %w", err))
+		}
+	}
+	for _, cert := range []struct {
+		name     string
+		inline   string
+		filename string
+	}{
+		{"root_cert", c.RootCert, c.RootCertFile},
+		{"intermediate_cert", c.IntermediateCert, c.IntermediateCertFile},
+		{"encrypt_cert_file", "", c.EncryptCertFile},
+	} {
+		if data, ok := readConfigFile(cert.inline, cert.filename); ok {
+			if _, err := ncrypto.DecodeCertificate([]byte(data)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", cert.name, err))
+			}
+		}
+	}
+	return errs
+}
+
+// readConfigFile 返回证书内容(inline)，没有设置时读取 filename 的内容。
+func readConfigFile(inline, filename string) (string, bool) {
+	if inline != "" {
+		return inline, true
+	}
+	if filename == "" {
+		return "", false
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Options 返回配置对应的 OptionFunc。
+func (c *Config) Options() []OptionFunc {
+	var opts []OptionFunc
+	if c.Timeout > 0 {
+		opts = append(opts, WithHTTPClient(&http.Client{Timeout: time.Duration(c.Timeout)}))
+	}
+	if c.GatewayURL != "" {
+		opts = append(opts, WithGateway(c.GatewayURL))
+	}
+	if c.EncryptCertFile != "" {
+		opts = append(opts, WithEncryptKeyFile(c.EncryptCertFile))
+	}
+	switch c.AccessType {
+	case AccessTypeAcquirer:
+		opts = append(opts, WithAcquirer(c.AcqInsCode))
+	case AccessTypePlatform:
+		opts = append(opts, WithPlatform(SubMerchant{Id: c.SubMerId, Name: c.SubMerName, Abbr: c.SubMerAbbr}))
+	}
+	return opts
+}
+
+// WithGateway 设置银联网关地址，用于代理或者银联提供的其它网关。
+func WithGateway(gateway string) OptionFunc {
+	return func(c *Client) {
+		if gateway != "" {
+			c.host = strings.TrimRight(gateway, "/")
+		}
+	}
+}
+
+// NewWithConfig 根据配置初始银联客户端，会先校验配置，然后加载商户私钥证书、银联根证书和中间证书。
+//
+// 如果设置了 EncryptCertFile 并且该文件存在，会从该文件加载敏感信息加密证书；否则需要调用 LoadEncryptKey 或者 RefreshEncryptKey 从银联获取。
+//
+// opts 在配置对应的 OptionFunc 之后执行。
+func NewWithConfig(config *Config, opts ...OptionFunc) (*Client, error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config.newClient(append(config.Options(), opts...))
+}
+
+// newClient 根据已经校验过的配置创建 Client，opts 为完整的 OptionFunc 列表。
+func (c *Config) newClient(opts []OptionFunc) (*Client, error) {
+	var pfx = c.PFX
+	if len(pfx) == 0 {
+		data, err := os.ReadFile(c.PFXFile)
+		if err != nil {
+			return nil, fmt.Errorf("pfx_file: %w", err)
+		}
+		pfx = data
+	}
+
+	client, err := New(pfx, c.PFXPassword, c.MerchantId, c.IsProduction(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("pfx: %w", err)
+	}
+	if err = loadCert(c.RootCert, c.RootCertFile, client.LoadRootCert, client.LoadRootCertFromFile); err != nil {
+		return nil, fmt.Errorf("root_cert: %w", err)
+	}
+	if err = loadCert(c.IntermediateCert, c.IntermediateCertFile, client.LoadIntermediateCert, client.LoadIntermediateCertFromFile); err != nil {
+		return nil, fmt.Errorf("intermediate_cert: %w", err)
+	}
+	if c.EncryptCertFile != "" {
+		if err = client.LoadEncryptKeyFromFile(c.EncryptCertFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("encrypt_cert_file: %w", err)
+		}
+	}
+	return client, nil
+}
+
+func loadCert(s, filename string, load, loadFromFile func(string) error) error {
+	if s != "" {
+		return load(s)
+	}
+	return loadFromFile(filename)
+}
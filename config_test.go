@@ -0,0 +1,178 @@
+package unionpay
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	var pki = newTestPKI(t)
+	var dir = t.TempDir()
+	var rootFile = filepath.Join(dir, "root.cer")
+	var interFile = filepath.Join(dir, "inter.cer")
+	if err := os.WriteFile(rootFile, []byte(pki.rootPEM), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(interFile, []byte(pki.interPEM), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var write = func(name, content string) string {
+		var filename = filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	var valid = "merchant_id: " + kTestMerchantId + "\npfx_file: " + kTestPFXFile + "\npfx_password: " + kTestPFXPass + "\nroot_cert_file: " + rootFile + "\nintermediate_cert_file: " + interFile + "\ntimeout: 15s\n"
+
+	var tests = []struct {
+		name     string
+		filename string
+		env      map[string]string
+		problems []string // 错误信息中需要包含的内容，为空时表示加载成功
+	}{
+		{"yaml", write("valid.yaml", valid), nil, nil},
+		{"json", write("valid.json", `{"merchant_id":"`+kTestMerchantId+`","pfx_file":"`+kTestPFXFile+`","pfx_password":"`+kTestPFXPass+`","root_cert_file":"`+rootFile+`","intermediate_cert_file":"`+interFile+`"}`), nil, nil},
+		{"env only", "", map[string]string{
+			"UNIONPAY_MERCHANT_ID":       kTestMerchantId,
+			"UNIONPAY_PFX_FILE":          kTestPFXFile,
+			"UNIONPAY_PFX_PASSWORD":      kTestPFXPass,
+			"UNIONPAY_ROOT_CERT":         pki.rootPEM,
+			"UNIONPAY_INTERMEDIATE_CERT": pki.interPEM,
+		}, nil},
+		{"missing file", filepath.Join(dir, "missing.yaml"), nil, []string{"missing.yaml", "merchant_id is required", "pfx_file is required"}},
+		{"decode error", write("invalid.yaml", "merchant_id: [\n"), nil, []string{"invalid.yaml", "merchant_id is required"}},
+		{"unsupported extension", write("config.toml", ""), nil, []string{"unsupported config file extension", "root_cert_file is required"}},
+		{"env error", write("env.yaml", valid), map[string]string{"UNIONPAY_TIMEOUT": "15", "UNIONPAY_ENVIRONMENT": "staging"}, []string{"UNIONPAY_TIMEOUT", "environment must be"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{"UNIONPAY_MERCHANT_ID", "UNIONPAY_PFX_FILE", "UNIONPAY_PFX_PASSWORD", "UNIONPAY_ROOT_CERT", "UNIONPAY_INTERMEDIATE_CERT", "UNIONPAY_TIMEOUT", "UNIONPAY_ENVIRONMENT"} {
+				t.Setenv(name, test.env[name])
+				if _, ok := test.env[name]; !ok {
+					os.Unsetenv(name)
+				}
+			}
+
+			config, err := LoadConfig(test.filename)
+			if len(test.problems) == 0 {
+				if err != nil {
+					t.Fatalf("LoadConfig() error = %v", err)
+				}
+				if _, err = NewWithConfig(config); err != nil {
+					t.Fatalf("NewWithConfig() error = %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("LoadConfig() returned %+v, want error", config)
+			}
+			for _, problem := range test.problems {
+				if !strings.Contains(err.Error(), problem) {
+					t.Errorf("LoadConfig() error = %v, want it to contain %q", err, problem)
+				}
+			}
+		})
+	}
+}
+
+// Validate 会解析证书，并一次返回所有的问题。
+func TestConfig_Validate(t *testing.T) {
+	var pki = newTestPKI(t)
+	var dir = t.TempDir()
+	var write = func(name, content string) string {
+		var filename = filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	var valid = &Config{
+		MerchantId:           kTestMerchantId,
+		PFXFile:              kTestPFXFile,
+		PFXPassword:          kTestPFXPass,
+		RootCert:             pki.rootPEM,
+		IntermediateCertFile: write("inter.cer", pki.interPEM),
+		EncryptCertFile:      filepath.Join(dir, "encrypt.cer"),
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	var broken = &Config{
+		PFXFile:              kTestPFXFile,
+		PFXPassword:          "wrong password",
+		RootCert:             "invalid root cert",
+		IntermediateCertFile: write("broken_inter.cer", "invalid intermediate cert"),
+		EncryptCertFile:      write("broken_encrypt.cer", "invalid encrypt cert"),
+		Timeout:              -1,
+	}
+	var err = broken.Validate()
+	if err == nil {
+		t.Fatal("Validate() accepted a broken config")
+	}
+	var cErr *ConfigError
+	if !errors.As(err, &cErr) {
+		t.Fatalf("Validate() error = %v, want ConfigError", err)
+	}
+	for _, problem := range []string{"merchant_id is required", "timeout must not be negative", "pfx:", "root_cert:", "intermediate_cert:", "encrypt_cert_file:"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Validate() error = %v, want it to contain %q", err, problem)
+		}
+	}
+
+	// 证书有问题但字段没有问题时，不会返回 ConfigError
+	var pfx = *valid
+	pfx.PFX = []byte("invalid pfx")
+	if err = pfx.Validate(); err == nil || errors.As(err, &cErr) || !strings.Contains(err.Error(), "pfx:") {
+		t.Fatalf("Validate() error = %v, want a pfx error only", err)
+	}
+}
+
+func TestRegistry_Add(t *testing.T) {
+	var pki = newTestPKI(t)
+	pfx, err := os.ReadFile(kTestPFXFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var registry = NewRegistry()
+	var config = &Config{
+		MerchantId:       kTestMerchantId,
+		PFX:              pfx,
+		PFXPassword:      kTestPFXPass,
+		RootCert:         pki.rootPEM,
+		IntermediateCert: pki.interPEM,
+	}
+	client, err := registry.Add(config, WithClock(ClockFunc(time.Now)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered, _ := registry.Client(kTestMerchantId); registered != client {
+		t.Fatal("Client() did not return the added client")
+	}
+	if client.VerifierCache() != registry.VerifierCache(false) {
+		t.Fatal("client does not share the registry verifier cache")
+	}
+	if err = client.VerifySign(testSignedValues(t, pki, "")); err != nil {
+		t.Fatal(err)
+	}
+
+	var cErr *ConfigError
+	if _, err = registry.Add(&Config{MerchantId: "777290058165622"}); !errors.As(err, &cErr) {
+		t.Fatalf("Add() error = %v, want ConfigError", err)
+	}
+	if err = registry.Load(&Config{}, &Config{MerchantId: "777290058165623", PFX: []byte("invalid"), RootCert: pki.rootPEM, IntermediateCert: pki.interPEM}); err == nil {
+		t.Fatal("Load() accepted invalid configs")
+	} else if !strings.Contains(err.Error(), "777290058165623") || !errors.As(err, &cErr) {
+		t.Fatalf("Load() error = %v, want errors of both configs", err)
+	}
+}
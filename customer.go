@@ -97,10 +97,6 @@ func (c *Client) EncryptCustomer(customer *Customer, accNo string) (string, erro
 		v.Set("encryptedInfo", encryptedInfo)
 	}
 
-	var r = internal.EncodeValues(v)
-	if r != "" {
-		r = "{" + r + "}"
-	}
-
+	var r = internal.EncodeBraced(v)
 	return base64.StdEncoding.EncodeToString([]byte(r)), nil
 }
@@ -0,0 +1,195 @@
+package unionpay
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const kEncryptKeyRefreshInterval = 24 * time.Hour
+
+// EncryptCertChangeFunc 敏感信息加密证书发生变化时的回调函数。
+//
+// oldCertId 为变化之前的证书 id，首次加载证书时为空字符串。
+type EncryptCertChangeFunc func(oldCertId, newCertId string)
+
+// WithEncryptKeyFile 设置敏感信息加密证书的本地缓存文件。
+//
+// 每次从银联成功获取到加密证书之后，都会将其写入该文件；当无法访问银联网关时，RefreshEncryptKey 会从该文件加载最后一次获取成功的证书。
+func WithEncryptKeyFile(filename string) OptionFunc {
+	return func(c *Client) {
+		c.encryptKeyFile = filename
+	}
+}
+
+// OnEncryptCertChange 注册敏感信息加密证书 id(encryptCertId) 发生变化时的回调函数。
+//
+// 回调函数在证书替换完成之后调用，此时 EncryptCertId() 已经返回新的证书 id。
+func (c *Client) OnEncryptCertChange(fn EncryptCertChangeFunc) {
+	if fn == nil {
+		return
+	}
+	c.encryptMu.Lock()
+	c.encryptListeners = append(c.encryptListeners, fn)
+	c.encryptMu.Unlock()
+}
+
+// LoadEncryptKey 银联加密公钥更新查询接口（敏感加密证书）。
+//
+// 商户定期（1天1次）向银联全渠道系统发起获取加密公钥信息交易。在加密公钥证书更新期间，全渠道系统支持新老证书的共同使用，新老证书并行期为1个月。全渠道系统向商户返回最新的加密公钥证书，由商户服务器替换本地证书。
+//
+// 如果通过 WithEncryptKeyFile 设置了本地缓存文件，获取成功之后会将证书写入该文件；此时新证书已经生效，写入失败只会通过 WithLogger 设置的日志记录，不会返回错误信息。
+//
+// 文档地址：https://open.unionpay.com/tjweb/acproduct/APIList?acpAPIId=758&apiservId=448&version=V2.2&bussType=0
+func (c *Client) LoadEncryptKey(ctx context.Context) error {
+	var values = url.Values{}
+	values.Set("accessType", c.accessType)
+	values.Set("channelType", "07") // 渠道类型
+	values.Set("txnType", "95")     // 交易类型 95-银联加密公钥更新查询
+	values.Set("txnSubType", "00")  // 交易子类型 默认00
+	values.Set("bizType", "000000") // 业务类型  默认
+	values.Set("certType", "01")    // 01：敏感信息加密公钥(只有01可用)
+	var txnTime = c.txnTime()
+	values.Set("orderId", txnTime)
+	values.Set("txnTime", txnTime)
+
+	var rValues, err = c.Request(ctx, kBackTrans, values)
+	if err != nil {
+		return err
+	}
+	var rErr = Error{Code: Code(rValues.Get("respCode")), Msg: rValues.Get("respMsg")}
+	if err = rErr.err(); err != nil {
+		return err
+	}
+	var cert = []byte(strings.ReplaceAll(rValues.Get("encryptPubKeyCert"), "\r", "\n"))
+
+	certificate, err := c.decodeCertificate(cert)
+	if err != nil {
+		return err
+	}
+	if err = c.setEncryptCert(certificate); err != nil {
+		return err
+	}
+
+	if c.encryptKeyFile != "" {
+		if err = writeFile(c.encryptKeyFile, cert); err != nil && c.logger != nil {
+			c.logger.LogAttrs(ctx, slog.LevelWarn, "unionpay encrypt key file", slog.String("file", c.encryptKeyFile), slog.String("error", err.Error()))
+		}
+	}
+	return nil
+}
+
+// LoadEncryptKeyFromFile 从文件加载银联敏感加密证书。
+func (c *Client) LoadEncryptKeyFromFile(filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	certificate, err := c.decodeCertificate(b)
+	if err != nil {
+		return err
+	}
+	return c.setEncryptCert(certificate)
+}
+
+// RefreshEncryptKey 从银联获取最新的敏感信息加密证书。
+//
+// 获取失败（如无法访问银联网关）并且当前还没有可用的证书时，会从 WithEncryptKeyFile 设置的本地缓存文件加载最后一次获取成功的证书，此时依然会返回获取失败的错误信息。
+func (c *Client) RefreshEncryptKey(ctx context.Context) error {
+	var err = c.LoadEncryptKey(ctx)
+	c.instrumentEncryptKeyRefresh(ctx, err)
+	if err == nil {
+		return nil
+	}
+
+	if c.EncryptCertId() == "" && c.encryptKeyFile != "" {
+		_ = c.LoadEncryptKeyFromFile(c.encryptKeyFile)
+	}
+	return err
+}
+
+// StartEncryptKeyRefresher 启动后台任务，每隔 interval 调用一次 RefreshEncryptKey 更新敏感信息加密证书，interval 小于等于 0 时为 24 小时。
+//
+// 本方法会先同步执行一次 RefreshEncryptKey，如果执行之后依然没有可用的证书，则返回错误信息并且不会启动后台任务。
+//
+// 后台任务在 ctx 结束时退出，更新过程中产生的错误信息会通过 onError 回调。
+func (c *Client) StartEncryptKeyRefresher(ctx context.Context, interval time.Duration, onError func(err error)) error {
+	if interval <= 0 {
+		interval = kEncryptKeyRefreshInterval
+	}
+
+	if err := c.RefreshEncryptKey(ctx); err != nil {
+		if c.EncryptCertId() == "" {
+			return err
+		}
+		if onError != nil {
+			onError(err)
+		}
+	}
+
+	go func() {
+		var ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.RefreshEncryptKey(ctx); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (c *Client) setEncryptCert(certificate *x509.Certificate) error {
+	publicKey, _ := certificate.PublicKey.(*rsa.PublicKey)
+	if publicKey == nil {
+		return errors.New("key is not a valid *rsa.PublicKey")
+	}
+	var certId = certificate.SerialNumber.String()
+
+	c.encryptMu.Lock()
+	var oldCertId = c.encryptCertId
+	c.encryptPublicKey = publicKey
+	c.encryptCertId = certId
+	c.encryptCert = certificate
+	var listeners = c.encryptListeners
+	c.encryptMu.Unlock()
+
+	if oldCertId != certId {
+		for _, fn := range listeners {
+			fn(oldCertId, certId)
+		}
+	}
+	return nil
+}
+
+// writeFile 先写入临时文件再重命名，避免进程中断时留下不完整的证书文件。
+func writeFile(filename string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
@@ -0,0 +1,153 @@
+package unionpay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testEncryptKeyGateway 模拟银联加密公钥更新查询接口，certPEM 为应答中的 encryptPubKeyCert。
+func testEncryptKeyGateway(t *testing.T, pki *testPKI, respCode, certPEM string) string {
+	var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		if api != kBackTrans || values.Get("txnType") != "95" {
+			t.Errorf("unexpected request %s txnType=%s", api, values.Get("txnType"))
+		}
+		var rValues = testResponse(values, respCode)
+		if certPEM != "" {
+			rValues.Set("encryptPubKeyCert", certPEM)
+		}
+		return rValues
+	})
+	return server.URL
+}
+
+func TestClient_LoadEncryptKey(t *testing.T) {
+	var pki = newTestPKI(t)
+	var cert, certPEM = pki.issue(t, time.Now().Add(24*time.Hour))
+	var filename = filepath.Join(t.TempDir(), "encrypt.cer")
+
+	var client = newTestClient(t, pki, WithGateway(testEncryptKeyGateway(t, pki, string(CodeSuccess), certPEM)), WithEncryptKeyFile(filename))
+
+	var changes []string
+	client.OnEncryptCertChange(func(oldCertId, newCertId string) {
+		changes = append(changes, oldCertId+"->"+newCertId)
+	})
+
+	if err := client.LoadEncryptKey(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.EncryptCertId(); got != cert.SerialNumber.String() {
+		t.Fatalf("EncryptCertId() = %q, want %q", got, cert.SerialNumber.String())
+	}
+	if len(changes) != 1 || changes[0] != "->"+cert.SerialNumber.String() {
+		t.Fatalf("changes = %v", changes)
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != certPEM {
+		t.Fatalf("cached cert does not match the gateway response")
+	}
+}
+
+func TestClient_LoadEncryptKeyWriteFailure(t *testing.T) {
+	var pki = newTestPKI(t)
+	var cert, certPEM = pki.issue(t, time.Now().Add(24*time.Hour))
+	var filename = filepath.Join(t.TempDir(), "missing", "encrypt.cer")
+
+	var buf bytes.Buffer
+	var logger = slog.New(slog.NewTextHandler(&buf, nil))
+	var client = newTestClient(t, pki, WithGateway(testEncryptKeyGateway(t, pki, string(CodeSuccess), certPEM)), WithEncryptKeyFile(filename), WithLogger(logger))
+
+	if err := client.LoadEncryptKey(context.Background()); err != nil {
+		t.Fatalf("LoadEncryptKey() error = %v, want nil after the cert is swapped in", err)
+	}
+	if got := client.EncryptCertId(); got != cert.SerialNumber.String() {
+		t.Fatalf("EncryptCertId() = %q, want %q", got, cert.SerialNumber.String())
+	}
+	if !strings.Contains(buf.String(), "unionpay encrypt key file") {
+		t.Fatalf("write failure was not logged: %s", buf.String())
+	}
+}
+
+func TestClient_LoadEncryptKeyError(t *testing.T) {
+	var pki = newTestPKI(t)
+
+	var tests = []struct {
+		name     string
+		respCode string
+		certPEM  string
+		target   error
+	}{
+		{"failure", string(CodeFailure), "", Error{Code: CodeFailure}},
+		{"bad cert", string(CodeSuccess), "invalid cert", nil},
+		{"missing cert", string(CodeSuccess), "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var filename = filepath.Join(t.TempDir(), "encrypt.cer")
+			var client = newTestClient(t, pki, WithGateway(testEncryptKeyGateway(t, pki, test.respCode, test.certPEM)), WithEncryptKeyFile(filename))
+
+			var err = client.LoadEncryptKey(context.Background())
+			if err == nil {
+				t.Fatal("LoadEncryptKey() error = nil")
+			}
+			if test.target != nil && !errors.Is(err, test.target) {
+				t.Fatalf("LoadEncryptKey() error = %v, want %v", err, test.target)
+			}
+			if got := client.EncryptCertId(); got != "" {
+				t.Fatalf("EncryptCertId() = %q, want empty", got)
+			}
+			if _, err = os.Stat(filename); !os.IsNotExist(err) {
+				t.Fatalf("cache file should not be written, stat error = %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_RefreshEncryptKeyFallback(t *testing.T) {
+	var pki = newTestPKI(t)
+	var cached, cachedPEM = pki.issue(t, time.Now().Add(24*time.Hour))
+	var filename = filepath.Join(t.TempDir(), "encrypt.cer")
+	if err := os.WriteFile(filename, []byte(cachedPEM), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var client = newTestClient(t, pki, WithGateway(testEncryptKeyGateway(t, pki, string(CodeSystemBusy), "")), WithEncryptKeyFile(filename))
+
+	var err = client.RefreshEncryptKey(context.Background())
+	if !errors.Is(err, Error{Code: CodeSystemBusy}) {
+		t.Fatalf("RefreshEncryptKey() error = %v, want %s", err, CodeSystemBusy)
+	}
+	if got := client.EncryptCertId(); got != cached.SerialNumber.String() {
+		t.Fatalf("EncryptCertId() = %q, want cached %q", got, cached.SerialNumber.String())
+	}
+
+	// 已经有可用的证书时不会再从缓存文件加载
+	var current, currentPEM = pki.issue(t, time.Now().Add(24*time.Hour))
+	client = newTestClient(t, pki, WithGateway(testEncryptKeyGateway(t, pki, string(CodeSystemBusy), "")), WithEncryptKeyFile(filename))
+	certificate, err := client.decodeCertificate([]byte(currentPEM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.setEncryptCert(certificate); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.RefreshEncryptKey(context.Background()); err == nil {
+		t.Fatal("RefreshEncryptKey() error = nil")
+	}
+	if got := client.EncryptCertId(); got != current.SerialNumber.String() {
+		t.Fatalf("EncryptCertId() = %q, want current %q", got, current.SerialNumber.String())
+	}
+}
@@ -1,17 +1,15 @@
 module github.com/smartwalle/unionpay/examples
 
-go 1.18
+go 1.21
 
-require (
-	github.com/smartwalle/unionpay v0.0.6
-	github.com/smartwalle/xid v1.0.7
-)
+require github.com/smartwalle/unionpay v0.0.6
 
 require (
 	github.com/smartwalle/ncrypto v1.0.4 // indirect
 	github.com/smartwalle/ngx v1.0.12 // indirect
 	github.com/smartwalle/nhttp v0.0.10 // indirect
 	github.com/smartwalle/nsign v1.0.9 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/smartwalle/unionpay => ../
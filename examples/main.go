@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/smartwalle/unionpay"
-	"github.com/smartwalle/xid"
 	"log"
 	"net/http"
 )
@@ -52,7 +51,7 @@ func main() {
 	})
 
 	http.HandleFunc("/unionpay/web", func(writer http.ResponseWriter, request *http.Request) {
-		var payment, err = client.CreateWebPayment(context.Background(), fmt.Sprintf("%d", xid.Next()), "100", kServerDomain+"/unionpay/front", kServerDomain+"/unionpay/back")
+		var payment, err = client.CreateWebPayment(context.Background(), client.NewOrderId(), "100", kServerDomain+"/unionpay/front", kServerDomain+"/unionpay/back")
 		if err != nil {
 			writer.Write([]byte(err.Error()))
 			return
@@ -63,7 +62,7 @@ func main() {
 	})
 
 	http.HandleFunc("/unionpay/app", func(writer http.ResponseWriter, request *http.Request) {
-		var payment, err = client.CreateAppPayment(context.Background(), fmt.Sprintf("%d", xid.Next()), "100", kServerDomain+"/union/back")
+		var payment, err = client.CreateAppPayment(context.Background(), client.NewOrderId(), "100", kServerDomain+"/union/back")
 		if err != nil {
 			writer.Write([]byte(err.Error()))
 			return
@@ -78,7 +77,7 @@ func main() {
 		var customer = &unionpay.Customer{}
 		customer.SMSCode = "111111"
 
-		var payment, err = client.CreateAccountPayment(context.Background(), fmt.Sprintf("%d", xid.Next()), "100", kServerDomain+"/unionpay/back", "6216261000000000018", customer)
+		var payment, err = client.CreateAccountPayment(context.Background(), client.NewOrderId(), "100", kServerDomain+"/unionpay/back", "6216261000000000018", customer)
 		if err != nil {
 			writer.Write([]byte(err.Error()))
 			return
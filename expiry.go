@@ -0,0 +1,106 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+)
+
+// kExpiryGracePeriod 订单支付超时时间(payTimeout)之后的宽限时长，用于容忍双方的时钟误差以及银联的处理延迟。
+const kExpiryGracePeriod = 5 * time.Minute
+
+// WithDefaultPayTimeout 设置消费类交易默认的订单支付超时时长，相对于订单发送时间(txnTime)，参考 ConsumeRequest.PayTimeout。
+//
+// 通过请求结构体的 PayTimeout 或者 WithPayload 指定了 payTimeout 的交易不受影响。
+func WithDefaultPayTimeout(timeout time.Duration) OptionFunc {
+	return func(c *Client) {
+		c.payTimeout = timeout
+	}
+}
+
+// applyPayTimeout 在请求参数编码完成之后根据最终的 txnTime 计算 payTimeout，按照北京时间格式化。
+//
+// timeout 为请求结构体中的 PayTimeout，大于 0 时替换 WithPayload 设置的 payTimeout；
+// 否则使用 WithPayload 设置的 payTimeout，都没有设置时使用 WithDefaultPayTimeout 设置的时长。
+func (c *Client) applyPayTimeout(values url.Values, timeout time.Duration) error {
+	if timeout <= 0 {
+		if payTimeout := values.Get("payTimeout"); payTimeout != "" {
+			var v = &validator{}
+			v.timestamp("payTimeout", payTimeout)
+			return v.err()
+		}
+		timeout = c.payTimeout
+	}
+	if timeout <= 0 {
+		return nil
+	}
+	if deadline, ok := payDeadline(values.Get("txnTime"), timeout); ok {
+		values.Set("payTimeout", deadline.Format(kTimeFormat))
+	}
+	return nil
+}
+
+func payDeadline(txnTime string, timeout time.Duration) (time.Time, bool) {
+	sent, err := ParseTxnTime(txnTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return sent.Add(timeout), true
+}
+
+// OrderExpiry 订单过期检查的结果，参考 CheckOrderExpiry。
+type OrderExpiry struct {
+	Expired     bool             // 订单是否已经确定过期，为 true 时可以释放库存等资源
+	Deadline    time.Time        // 订单支付超时时间
+	State       TransactionState // 查询到的交易状态，未到支付超时时间时为 TransactionStateUnknown
+	Transaction *Transaction     // 交易状态查询的结果
+}
+
+// CheckOrderExpiry 检查消费订单是否已经确定过期。
+//
+// timeout 为下单时使用的支付超时时长（参考 ConsumeRequest.PayTimeout），小于等于 0 时使用 WithDefaultPayTimeout 设置的时长。
+//
+// 未到支付超时时间（包含 5 分钟的宽限时长）时不会查询银联，直接返回未过期；否则通过交易状态查询接口(GetTransaction)查询：
+//
+// 查无此交易或者交易失败时，订单确定已经过期；交易成功、处理中或者超时扣款(TransactionStatePaidAfterTimeout)时，订单没有过期；无法确定交易状态时返回错误信息。
+//
+// 超时扣款时持卡人已被扣款，不能释放库存等资源，需要发起退货或者继续履约。
+func (c *Client) CheckOrderExpiry(ctx context.Context, orderId, txnTime string, timeout time.Duration, opts ...CallOption) (*OrderExpiry, error) {
+	if timeout <= 0 {
+		timeout = c.payTimeout
+	}
+	if timeout <= 0 {
+		return nil, errors.New("pay timeout is not set")
+	}
+
+	deadline, ok := payDeadline(txnTime, timeout)
+	if !ok {
+		return nil, errors.New("invalid txnTime " + txnTime)
+	}
+
+	var expiry = &OrderExpiry{}
+	expiry.Deadline = deadline
+	if c.now().Before(deadline.Add(kExpiryGracePeriod)) {
+		return expiry, nil
+	}
+
+	transaction, err := c.GetTransaction(ctx, orderId, txnTime, opts...)
+	expiry.Transaction = transaction
+	expiry.State = ResolveTransaction(transaction, err)
+
+	switch expiry.State {
+	case TransactionStateNotFound, TransactionStateFailed:
+		expiry.Expired = true
+		return expiry, nil
+	case TransactionStateSucceeded, TransactionStatePending, TransactionStatePaidAfterTimeout:
+		return expiry, nil
+	}
+	if err == nil {
+		// 查询应答失败时返回应答中的 Error
+		if err = transaction.err(); err == nil {
+			err = ErrTransactionNotFinal
+		}
+	}
+	return expiry, err
+}
@@ -0,0 +1,84 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestClient_PayTimeout(t *testing.T) {
+	var pki = newTestPKI(t)
+	var now = time.Date(2026, 10, 19, 18, 0, 0, 0, kBeijing)
+	var payload = func(key, value string) CallOption {
+		return WithPayload(NewPayload().AddParam(key, value))
+	}
+
+	var tests = []struct {
+		name     string
+		defaults time.Duration // WithDefaultPayTimeout
+		txnTime  string        // 请求结构体中的 txnTime
+		timeout  time.Duration // 请求结构体中的 PayTimeout
+		opts     []CallOption
+		want     string
+	}{
+		{"not set", 0, "", 0, nil, ""},
+		{"client clock", 0, "", 30 * time.Minute, nil, "20261019183000"},
+		{"txnTime of request", 0, "20261019120000", 30 * time.Minute, nil, "20261019123000"},
+		{"txnTime of payload", 0, "", 30 * time.Minute, []CallOption{payload("txnTime", "20261019100000")}, "20261019103000"},
+		{"default", 15 * time.Minute, "20261019120000", 0, nil, "20261019121500"},
+		{"overrides default", 15 * time.Minute, "20261019120000", time.Hour, nil, "20261019130000"},
+		{"payload", 15 * time.Minute, "20261019120000", 0, []CallOption{payload("payTimeout", "20261019200000")}, "20261019200000"},
+		{"request wins over payload", 0, "20261019120000", time.Hour, []CallOption{payload("payTimeout", "20261019200000")}, "20261019130000"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var client = newTestClient(t, pki, WithDefaultPayTimeout(test.defaults), WithClock(ClockFunc(func() time.Time { return now })))
+
+			var req = &WebPaymentRequest{}
+			req.TxnTime = test.txnTime
+			req.PayTimeout = test.timeout
+			req.TxnAmt = "1000"
+			req.FrontURL = "https://example.com/return"
+			req.BackURL = "https://example.com/notify"
+			payment, err := client.CreateWebPaymentWith(context.Background(), req, test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if payment.PayTimeout != test.want {
+				t.Fatalf("PayTimeout = %q, want %q", payment.PayTimeout, test.want)
+			}
+		})
+	}
+
+	var client = newTestClient(t, pki)
+	var req = &WebPaymentRequest{}
+	req.PayTimeout = -time.Minute
+	req.TxnAmt = "1000"
+	req.FrontURL = "https://example.com/return"
+	req.BackURL = "https://example.com/notify"
+	if _, err := client.CreateWebPaymentWith(context.Background(), req); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("CreateWebPaymentWith() error = %v, want ValidationError", err)
+	}
+}
+
+// 非消费类交易不会使用 WithDefaultPayTimeout 设置的时长。
+func TestClient_PayTimeoutNotSent(t *testing.T) {
+	var pki = newTestPKI(t)
+	var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		for key := range values {
+			if key == "payTimeout" {
+				t.Errorf("unexpected parameter %s=%s", key, values.Get(key))
+			}
+		}
+		return testResponse(values, "00")
+	})
+	var client = newTestClient(t, pki, WithGateway(server.URL), WithDefaultPayTimeout(time.Hour))
+
+	if _, err := client.Refund(context.Background(), "123456789012345678901", "20261019120000abcdef0002", "1000", "https://example.com/notify"); err != nil {
+		t.Fatal(err)
+	}
+}
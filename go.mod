@@ -1,10 +1,11 @@
 module github.com/smartwalle/unionpay
 
-go 1.18
+go 1.21
 
 require (
 	github.com/smartwalle/ncrypto v1.0.4
 	github.com/smartwalle/ngx v1.0.12
 	github.com/smartwalle/nhttp v0.0.10
 	github.com/smartwalle/nsign v1.0.9
+	gopkg.in/yaml.v3 v3.0.1
 )
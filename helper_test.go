@@ -0,0 +1,234 @@
+package unionpay
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/smartwalle/nsign"
+	"github.com/smartwalle/unionpay/internal"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	kTestPFXFile    = "testdata/acp_test_sign.pfx"
+	kTestPFXPass    = "000000"
+	kTestMerchantId = "777290058165621"
+)
+
+// testPKI 测试用的银联证书链：根证书 -> 中间证书 -> 银联签名公钥证书。
+type testPKI struct {
+	root      *x509.Certificate
+	rootPEM   string
+	inter     *x509.Certificate
+	interPEM  string
+	interKey  *rsa.PrivateKey
+	cert      *x509.Certificate
+	certPEM   string
+	certKey   *rsa.PrivateKey
+	keys      int
+	serial    int64
+	serialsMu sync.Mutex
+}
+
+var (
+	testKeyOnce sync.Once
+	testKeys    [6]*rsa.PrivateKey
+	testPKIs    atomic.Int64
+)
+
+// testKey 生成 RSA 密钥比较慢，所有测试共用两组密钥，相邻创建的两个 testPKI 使用不同的密钥。
+func testKey(t testing.TB, i int) *rsa.PrivateKey {
+	testKeyOnce.Do(func() {
+		for j := range testKeys {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				panic(err)
+			}
+			testKeys[j] = key
+		}
+	})
+	return testKeys[i]
+}
+
+func (pki *testPKI) key(t testing.TB, i int) *rsa.PrivateKey {
+	return testKey(t, pki.keys+i)
+}
+
+func newTestPKI(t testing.TB) *testPKI {
+	t.Helper()
+
+	var now = time.Now()
+	var pki = &testPKI{serial: 100}
+	var n = testPKIs.Add(1)
+	var name = strconv.FormatInt(n, 10)
+	pki.keys = int(n%2) * 3
+
+	var rootTpl = &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test UnionPay Root " + name},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	pki.root, pki.rootPEM = createTestCert(t, rootTpl, rootTpl, pki.key(t, 0), pki.key(t, 0))
+
+	var interTpl = &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test UnionPay Intermediate " + name},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	pki.inter, pki.interPEM = createTestCert(t, interTpl, pki.root, pki.key(t, 1), pki.key(t, 0))
+	pki.interKey = pki.key(t, 1)
+
+	pki.cert, pki.certPEM = pki.issue(t, now.Add(24*time.Hour))
+	pki.certKey = pki.key(t, 2)
+	return pki
+}
+
+func createTestCert(t testing.TB, tpl, parent *x509.Certificate, key, parentKey *rsa.PrivateKey) (*x509.Certificate, string) {
+	t.Helper()
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// issue 签发一个新的银联签名公钥证书，每次签发的证书序列号都不同。
+func (pki *testPKI) issue(t testing.TB, notAfter time.Time) (*x509.Certificate, string) {
+	t.Helper()
+
+	pki.serialsMu.Lock()
+	pki.serial++
+	var serial = pki.serial
+	pki.serialsMu.Unlock()
+
+	var tpl = &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "041@Z12400000000@中国银联股份有限公司@00000001"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	return createTestCert(t, tpl, pki.inter, pki.key(t, 2), pki.interKey)
+}
+
+// crl 生成由中间证书签发的证书吊销列表，PEM 格式。
+func (pki *testPKI) crl(t testing.TB, revoked ...*x509.Certificate) []byte {
+	t.Helper()
+
+	var tpl = &x509.RevocationList{
+		Number:     big.NewInt(time.Now().UnixNano()),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+	}
+	for _, cert := range revoked {
+		tpl.RevokedCertificateEntries = append(tpl.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   cert.SerialNumber,
+			RevocationTime: time.Now().Add(-time.Minute),
+		})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, tpl, pki.inter, pki.interKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+// sign 模拟银联对应答或者通知进行签名，certPEM 为空时使用默认的银联签名公钥证书。
+func (pki *testPKI) sign(t testing.TB, values url.Values, certPEM string) url.Values {
+	t.Helper()
+
+	if certPEM == "" {
+		certPEM = pki.certPEM
+	}
+	values.Del("signature")
+	values.Set("signPubKeyCert", certPEM)
+
+	var signer = nsign.New(nsign.WithMethod(internal.NewRSAMethod(crypto.SHA256, pki.certKey, nil)))
+	signature, err := signer.SignValues(values, nsign.WithIgnore("signature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values.Set("signature", base64.StdEncoding.EncodeToString(signature))
+	return values
+}
+
+// newTestClient 使用 testdata 中的商户私钥证书创建 Client，并加载 pki 的根证书和中间证书。
+func newTestClient(t testing.TB, pki *testPKI, opts ...OptionFunc) *Client {
+	t.Helper()
+
+	client, err := NewWithPFXFile(kTestPFXFile, kTestPFXPass, kTestMerchantId, false, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.LoadRootCert(pki.rootPEM); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.LoadIntermediateCert(pki.interPEM); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// testGatewayFunc 处理测试网关收到的请求，返回的应答会由 testGateway 签名；返回 nil 时表示由 testGatewayFunc 自行写入应答。
+type testGatewayFunc func(w http.ResponseWriter, api string, values url.Values) url.Values
+
+// newTestGateway 模拟银联网关，通过 WithGateway(server.URL) 使用。
+func newTestGateway(t testing.TB, pki *testPKI, fn testGatewayFunc) *httptest.Server {
+	t.Helper()
+
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var rValues = fn(w, r.URL.Path, r.PostForm)
+		if rValues == nil {
+			return
+		}
+		pki.sign(t, rValues, "")
+		w.Write([]byte(internal.EncodeValues(rValues)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testResponse 根据请求参数构造应答，respCode 为应答码。
+func testResponse(values url.Values, respCode string) url.Values {
+	var rValues = url.Values{}
+	for _, key := range []string{"version", "encoding", "bizType", "txnTime", "txnType", "txnSubType", "accessType", "merId", "orderId", "txnAmt", "reqReserved", "reserved"} {
+		if value := values.Get(key); value != "" {
+			rValues.Set(key, value)
+		}
+	}
+	rValues.Set("signMethod", "01")
+	rValues.Set("respCode", respCode)
+	rValues.Set("respMsg", "test "+respCode)
+	return rValues
+}
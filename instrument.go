@@ -0,0 +1,125 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	SignatureSourceResponse     = "response"     // 同步应答验签
+	SignatureSourceNotification = "notification" // 通知验签
+)
+
+// Instrumentation 用于接入监控系统，如 OpenTelemetry、Prometheus，参考 otelunionpay 和 promunionpay。
+type Instrumentation interface {
+	// StartRequest 在调用银联接口之前调用，此时 call 中只有 API 和 Values；返回的函数在调用结束之后调用。
+	StartRequest(ctx context.Context, call *Call) (context.Context, func(call *Call, err error))
+
+	// SignatureFailure 验签失败，source 为 SignatureSourceResponse 或者 SignatureSourceNotification。
+	SignatureFailure(ctx context.Context, source string, err error)
+
+	// Notification 收到银联通知，duplicate 表示是否为最近已经收到过的重复通知。
+	Notification(ctx context.Context, values url.Values, duplicate bool, err error)
+
+	// EncryptKeyRefresh 敏感信息加密证书更新的结果，更新成功时 err 为 nil。
+	EncryptKeyRefresh(ctx context.Context, certId string, err error)
+
+	// Throttled 请求被客户端限流，参数与 ThrottleFunc 相同。
+	Throttled(ctx context.Context, call *Call, reason string, wait time.Duration, err error)
+}
+
+// WithInstrumentation 设置监控。
+func WithInstrumentation(instrumentation Instrumentation) OptionFunc {
+	return func(c *Client) {
+		if instrumentation == nil {
+			return
+		}
+		c.instrumentation = instrumentation
+		c.notifications = newNotificationTracker(kNotificationTrackerSize)
+		c.Use(instrumentInterceptor(instrumentation))
+	}
+}
+
+func instrumentInterceptor(instrumentation Instrumentation) Interceptor {
+	return func(ctx context.Context, call *Call, next Handler) error {
+		ctx, end := instrumentation.StartRequest(ctx, call)
+		var err = next(ctx, call)
+		if call.VerifyError != nil && !errors.Is(call.VerifyError, ErrUnsignedResponse) {
+			var unverified *UnverifiedError
+			if !errors.As(call.VerifyError, &unverified) {
+				instrumentation.SignatureFailure(ctx, SignatureSourceResponse, call.VerifyError)
+			}
+		}
+		end(call, err)
+		return err
+	}
+}
+
+func (c *Client) instrumentNotification(values url.Values, verifyErr, err error) {
+	if c.instrumentation == nil {
+		return
+	}
+
+	var ctx = context.Background()
+	if verifyErr != nil {
+		c.instrumentation.SignatureFailure(ctx, SignatureSourceNotification, verifyErr)
+		err = verifyErr
+	}
+
+	var duplicate = false
+	if err == nil {
+		duplicate = c.notifications.seen(values)
+	}
+	c.instrumentation.Notification(ctx, values, duplicate, err)
+}
+
+func (c *Client) instrumentEncryptKeyRefresh(ctx context.Context, err error) {
+	if c.instrumentation == nil {
+		return
+	}
+	c.instrumentation.EncryptKeyRefresh(ctx, c.EncryptCertId(), err)
+}
+
+const kNotificationTrackerSize = 1024
+
+// notificationTracker 记录最近收到的通知，用于识别银联重发的重复通知。
+type notificationTracker struct {
+	mu    sync.Mutex
+	size  int
+	keys  []string
+	next  int
+	index map[string]struct{}
+}
+
+func newNotificationTracker(size int) *notificationTracker {
+	var nTracker = &notificationTracker{}
+	nTracker.size = size
+	nTracker.keys = make([]string, 0, size)
+	nTracker.index = make(map[string]struct{}, size)
+	return nTracker
+}
+
+// seen 记录通知，如果最近已经收到过相同的通知则返回 true。
+func (t *notificationTracker) seen(values url.Values) bool {
+	var key = values.Get("merId") + "|" + values.Get("orderId") + "|" + values.Get("txnTime") + "|" + values.Get("txnType") + "|" + values.Get("queryId") + "|" + values.Get("respCode")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.index[key]; ok {
+		return true
+	}
+
+	if len(t.keys) < t.size {
+		t.keys = append(t.keys, key)
+	} else {
+		delete(t.index, t.keys[t.next])
+		t.keys[t.next] = key
+		t.next = (t.next + 1) % t.size
+	}
+	t.index[key] = struct{}{}
+	return false
+}
@@ -0,0 +1,48 @@
+package unionpay
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Call 一次银联接口调用的信息，由 Client.Request 创建并依次传递给各 Interceptor。
+type Call struct {
+	API         string        // 接口地址，如 /gateway/api/backTransReq.do
+	Values      url.Values    // 签名之前的请求参数
+	Signed      url.Values    // 签名之后的请求参数，实际提交给银联的数据
+	Body        []byte        // 银联返回的原始数据
+	Response    url.Values    // 解析之后的应答参数，Client.Request 的返回结果
+	VerifyError error         // 验签结果，验签成功时为 nil
+	Latency     time.Duration // 从发起请求到读取完应答的耗时
+
+	sent bool // 请求是否已经开始提交给银联
+}
+
+// Handler 处理一次银联接口调用。
+type Handler func(ctx context.Context, call *Call) error
+
+// Interceptor 拦截器，用于在银联接口调用前后添加日志、监控、脱敏、故障注入等通用逻辑。
+//
+// 调用 next 之前 call 中只有 API 和 Values，可以修改 Values；调用 next 之后可以获取签名之后的请求参数、应答数据、验签结果以及耗时。
+//
+// 不调用 next 时不会请求银联，此时 Client.Request 返回 call.Response 或者 Interceptor 返回的错误信息。
+type Interceptor func(ctx context.Context, call *Call, next Handler) error
+
+// WithInterceptor 添加拦截器，多个拦截器按照添加的顺序依次执行，先添加的拦截器位于外层。
+func WithInterceptor(interceptors ...Interceptor) OptionFunc {
+	return func(c *Client) {
+		c.Use(interceptors...)
+	}
+}
+
+// Use 添加拦截器，参考 WithInterceptor。
+//
+// 需要在发起请求之前调用，不能与 Request 并发调用。
+func (c *Client) Use(interceptors ...Interceptor) {
+	for _, interceptor := range interceptors {
+		if interceptor != nil {
+			c.interceptors = append(c.interceptors, interceptor)
+		}
+	}
+}
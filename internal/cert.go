@@ -2,10 +2,17 @@ package internal
 
 import (
 	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 )
 
+// ErrCRLExpired 证书吊销列表已经过了下一次更新时间(NextUpdate)。
+var ErrCRLExpired = errors.New("crl has expired")
+
 func VerifyCert(rootCert, intermediateCert, cert *x509.Certificate) error {
 	var roots = x509.NewCertPool()
 	roots.AddCert(rootCert)
@@ -29,3 +36,46 @@ func VerifyCert(rootCert, intermediateCert, cert *x509.Certificate) error {
 	}
 	return nil
 }
+
+// ParseCRL 解析 PEM 或者 DER 格式的证书吊销列表，并使用 issuers 中的证书验证其签名，issuers 为空时不验证签名。
+//
+// 吊销列表的 NextUpdate 早于 now 时返回 ErrCRLExpired。
+func ParseCRL(b []byte, now time.Time, issuers ...*x509.Certificate) (*x509.RevocationList, error) {
+	if block, _ := pem.Decode(b); block != nil {
+		b = block.Bytes
+	}
+
+	crl, err := x509.ParseRevocationList(b)
+	if err != nil {
+		return nil, err
+	}
+	if !crl.NextUpdate.IsZero() && now.After(crl.NextUpdate) {
+		return nil, fmt.Errorf("%w: next update at %s", ErrCRLExpired, crl.NextUpdate.Format(time.RFC3339))
+	}
+
+	if len(issuers) == 0 {
+		return crl, nil
+	}
+	for _, issuer := range issuers {
+		if err = crl.CheckSignatureFrom(issuer); err == nil {
+			return crl, nil
+		}
+	}
+	return nil, err
+}
+
+// DecodeCertificate 将 PEM 或者 base64 编码的证书解码为 DER 格式。
+func DecodeCertificate(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("invalid certificate")
+	}
+
+	if data[0] == '-' {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("invalid certificate")
+		}
+		return block.Bytes, nil
+	}
+	return base64.StdEncoding.DecodeString(string(data))
+}
@@ -33,6 +33,28 @@ func EncodeValues(values url.Values) string {
 	return buf.String()
 }
 
+// EncodeBraced 将 values 编码为银联使用的 {key=value&key=value} 格式，用于 customerInfo、reserved、riskRateInfo 等字段。
+//
+// values 为空时返回空字符串。
+func EncodeBraced(values url.Values) string {
+	var s = EncodeValues(values)
+	if s == "" {
+		return ""
+	}
+	return "{" + s + "}"
+}
+
+// ParseBraced 解析 {key=value&key=value} 格式的数据。
+func ParseBraced(s string) (url.Values, error) {
+	if s == "" {
+		return url.Values{}, nil
+	}
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("invalid braced value %q", s)
+	}
+	return ParseQuery(s[1 : len(s)-1])
+}
+
 func ParseQuery(query string) (url.Values, error) {
 	m := make(url.Values)
 	err := parseQuery(m, query)
@@ -42,7 +64,7 @@ func ParseQuery(query string) (url.Values, error) {
 func parseQuery(m url.Values, query string) (err error) {
 	for query != "" {
 		var key string
-		key, query, _ = strings.Cut(query, "&")
+		key, query = cutPair(query)
 		if strings.Contains(key, ";") {
 			err = fmt.Errorf("invalid semicolon separator in query")
 			continue
@@ -69,3 +91,47 @@ func parseQuery(m url.Values, query string) (err error) {
 	}
 	return err
 }
+
+// cutPair 按照 & 切分参数。
+//
+// 值以 { 或者 [ 开头时，与之匹配的 } 或者 ] 之前的 & 不作为分隔符，如 reserved={a=1&b=2}、accSplitMerInfo=[{a=1&b=2}]；
+// 没有匹配的括号，或者括号之后不是 & 时，按照第一个 & 切分，避免 respMsg 等字段中单独出现的 { 影响后续参数。
+func cutPair(query string) (pair, rest string) {
+	var amp = strings.IndexByte(query, '&')
+	if eq := strings.IndexByte(query, '='); eq >= 0 && (amp < 0 || eq < amp) {
+		if end := closingBracket(query[eq+1:]); end >= 0 {
+			end += eq + 1
+			if end+1 == len(query) {
+				return query, ""
+			}
+			if query[end+1] == '&' {
+				return query[:end+1], query[end+2:]
+			}
+		}
+	}
+
+	if amp < 0 {
+		return query, ""
+	}
+	return query[:amp], query[amp+1:]
+}
+
+// closingBracket 返回与 s 开头的 { 或者 [ 匹配的 } 或者 ] 的位置，s 不以 { 或者 [ 开头或者没有匹配的括号时返回 -1。
+func closingBracket(s string) int {
+	if s == "" || (s[0] != '{' && s[0] != '[') {
+		return -1
+	}
+	var depth = 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	var tests = []struct {
+		query string
+		want  url.Values
+	}{
+		{"a=1&b=2", url.Values{"a": {"1"}, "b": {"2"}}},
+		{"reserved={a=1&b=2}&respCode=00", url.Values{"reserved": {"{a=1&b=2}"}, "respCode": {"00"}}},
+		{"reserved={a=1&b=2}", url.Values{"reserved": {"{a=1&b=2}"}}},
+		{"info={a={b=1&c=2}&d=3}&e=4", url.Values{"info": {"{a={b=1&c=2}&d=3}"}, "e": {"4"}}},
+		{"accSplitMerInfo=[{a=1&b=2},{a=3&b=4}]&accSplitType=0", url.Values{"accSplitMerInfo": {"[{a=1&b=2},{a=3&b=4}]"}, "accSplitType": {"0"}}},
+		{"accSplitData={accSplitMerInfo=[{a=1&b=2}]&accSplitType=0}&respCode=00", url.Values{"accSplitData": {"{accSplitMerInfo=[{a=1&b=2}]&accSplitType=0}"}, "respCode": {"00"}}},
+		{"respMsg=[失败&respCode=01", url.Values{"respMsg": {"[失败"}, "respCode": {"01"}}},
+		{"respMsg=失败{原因&respCode=01", url.Values{"respMsg": {"失败{原因"}, "respCode": {"01"}}},
+		{"respMsg={失败&respCode=01", url.Values{"respMsg": {"{失败"}, "respCode": {"01"}}},
+		{"respMsg={失败}原因&respCode=01", url.Values{"respMsg": {"{失败}原因"}, "respCode": {"01"}}},
+		{"respMsg=失败}&respCode=01", url.Values{"respMsg": {"失败}"}, "respCode": {"01"}}},
+		{"a&b={c=1&d=2}", url.Values{"a": {""}, "b": {"{c=1&d=2}"}}},
+		{"a=x+y%20z&b=", url.Values{"a": {"x+y%20z"}, "b": {""}}},
+		{"", url.Values{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			got, err := ParseQuery(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("ParseQuery() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseBraced(t *testing.T) {
+	var values = url.Values{"a": {"1"}, "b": {"2"}}
+	var s = EncodeBraced(values)
+	if s != "{a=1&b=2}" {
+		t.Fatalf("EncodeBraced() = %q", s)
+	}
+	got, err := ParseBraced(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Fatalf("ParseBraced() = %v, want %v", got, values)
+	}
+
+	for _, s := range []string{"{", "a=1", "{a=1"} {
+		if _, err = ParseBraced(s); err == nil {
+			t.Errorf("ParseBraced(%q) accepted an invalid value", s)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	ThrottleReasonRateLimit = "rate_limit" // 超过 WithRateLimit 设置的频率
+	ThrottleReasonInFlight  = "in_flight"  // 超过 WithMaxInFlight 设置的并发数
+)
+
+// ErrThrottled 等待限流期间 context 被取消或者超时，请求没有发送给银联。
+var ErrThrottled = errors.New("unionpay request throttled")
+
+// RateLimit 令牌桶限流配置。
+type RateLimit struct {
+	Rate  float64 // 每秒允许的请求数
+	Burst int     // 允许的突发请求数，小于 1 时为 1
+}
+
+// ThrottleFunc 请求被限流时的回调函数，在等待结束之后调用。
+//
+// reason 为 ThrottleReasonRateLimit 或者 ThrottleReasonInFlight；wait 为实际等待的时间；等待期间 context 被取消或者超时时 err 不为 nil。
+type ThrottleFunc func(ctx context.Context, call *Call, reason string, wait time.Duration, err error)
+
+// WithRateLimit 设置接口调用频率限制，每个 Client 独立计算。
+//
+// api 为接口地址，如 /gateway/api/queryTrans.do；txnType 为交易类型，如 00；为空时表示不区分。
+//
+// 请求会使用最匹配的一个限制：先匹配 api 和 txnType，再匹配 api，然后匹配 txnType（api 为空），最后匹配两者都为空的全局限制。
+//
+//	unionpay.WithRateLimit("/gateway/api/queryTrans.do", "00", unionpay.RateLimit{Rate: 5, Burst: 10})
+func WithRateLimit(api, txnType string, limit RateLimit) OptionFunc {
+	return func(c *Client) {
+		if limit.Rate <= 0 {
+			return
+		}
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+		var limiter = c.getLimiter()
+		limiter.buckets[limitKey{api: api, txnType: txnType}] = newTokenBucket(limit)
+	}
+}
+
+// WithMaxInFlight 设置同时进行中的银联接口调用的最大数量，超出时会等待其它调用结束。
+func WithMaxInFlight(n int) OptionFunc {
+	return func(c *Client) {
+		if n <= 0 {
+			return
+		}
+		var limiter = c.getLimiter()
+		limiter.inFlight = make(chan struct{}, n)
+	}
+}
+
+// OnThrottle 注册请求被限流时的回调函数。
+//
+// 需要在发起请求之前调用，不能与 Request 并发调用。
+func (c *Client) OnThrottle(fn ThrottleFunc) {
+	if fn == nil {
+		return
+	}
+	c.throttleListeners = append(c.throttleListeners, fn)
+}
+
+func (c *Client) getLimiter() *limiter {
+	if c.limiter == nil {
+		c.limiter = &limiter{buckets: make(map[limitKey]*tokenBucket)}
+	}
+	return c.limiter
+}
+
+func (c *Client) throttled(ctx context.Context, call *Call, reason string, wait time.Duration, err error) {
+	if c.instrumentation != nil {
+		c.instrumentation.Throttled(ctx, call, reason, wait, err)
+	}
+	for _, fn := range c.throttleListeners {
+		fn(ctx, call, reason, wait, err)
+	}
+}
+
+// limit 在发送请求之前进行限流，先获取令牌，再占用并发数。
+func (c *Client) limit(next Handler) Handler {
+	return func(ctx context.Context, call *Call) error {
+		if err := c.waitToken(ctx, call); err != nil {
+			return err
+		}
+
+		var inFlight = c.limiter.inFlight
+		if inFlight != nil {
+			select {
+			case inFlight <- struct{}{}:
+			default:
+				var start = time.Now()
+				select {
+				case inFlight <- struct{}{}:
+					c.throttled(ctx, call, ThrottleReasonInFlight, time.Since(start), nil)
+				case <-ctx.Done():
+					var err = fmt.Errorf("%w: %w", ErrThrottled, ctx.Err())
+					c.throttled(ctx, call, ThrottleReasonInFlight, time.Since(start), err)
+					return err
+				}
+			}
+			defer func() {
+				<-inFlight
+			}()
+		}
+		return next(ctx, call)
+	}
+}
+
+func (c *Client) waitToken(ctx context.Context, call *Call) error {
+	var bucket = c.limiter.bucket(call.API, call.Values.Get("txnType"))
+	if bucket == nil {
+		return nil
+	}
+
+	var start = time.Now()
+	var wait = bucket.reserve(start)
+	if wait <= 0 {
+		return nil
+	}
+
+	// 等待时间超过 context 的截止时间时直接返回，不再等待
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(start.Add(wait)) {
+		bucket.cancel(start)
+		var err = fmt.Errorf("%w: %w", ErrThrottled, context.DeadlineExceeded)
+		c.throttled(ctx, call, ThrottleReasonRateLimit, 0, err)
+		return err
+	}
+
+	var timer = time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		c.throttled(ctx, call, ThrottleReasonRateLimit, time.Since(start), nil)
+		return nil
+	case <-ctx.Done():
+		bucket.cancel(time.Now())
+		var err = fmt.Errorf("%w: %w", ErrThrottled, ctx.Err())
+		c.throttled(ctx, call, ThrottleReasonRateLimit, time.Since(start), err)
+		return err
+	}
+}
+
+type limitKey struct {
+	api     string
+	txnType string
+}
+
+type limiter struct {
+	buckets  map[limitKey]*tokenBucket
+	inFlight chan struct{}
+}
+
+func (l *limiter) bucket(api, txnType string) *tokenBucket {
+	if len(l.buckets) == 0 {
+		return nil
+	}
+	for _, key := range []limitKey{{api, txnType}, {api, ""}, {"", txnType}, {"", ""}} {
+		if bucket, ok := l.buckets[key]; ok {
+			return bucket
+		}
+	}
+	return nil
+}
+
+// tokenBucket 令牌桶，令牌数可以为负数，表示已经被预约的令牌。
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	var bucket = &tokenBucket{}
+	bucket.rate = limit.Rate
+	bucket.burst = float64(limit.Burst)
+	bucket.tokens = bucket.burst
+	return bucket
+}
+
+func (b *tokenBucket) advance(now time.Time) {
+	if b.last.IsZero() {
+		b.last = now
+		return
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// reserve 预约一个令牌，返回获得该令牌需要等待的时间。
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel 归还预约的令牌。
+func (b *tokenBucket) cancel(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(now)
+	if b.tokens++; b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
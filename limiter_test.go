@@ -0,0 +1,165 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Bucket(t *testing.T) {
+	var keys = []limitKey{
+		{kBackTrans, "04"},
+		{kBackTrans, ""},
+		{"", "00"},
+		{"", ""},
+	}
+
+	var tests = []struct {
+		name    string
+		keys    []limitKey // 已设置的限制
+		api     string
+		txnType string
+		want    limitKey
+		none    bool
+	}{
+		{"api and txnType", keys, kBackTrans, "04", keys[0], false},
+		{"api", keys, kBackTrans, "31", keys[1], false},
+		{"txnType", keys, kQueryTrans, "00", keys[2], false},
+		{"api before txnType", keys, kBackTrans, "00", keys[1], false},
+		{"global", keys, kQueryTrans, "95", keys[3], false},
+		{"txnType without global", keys[:3], kQueryTrans, "00", keys[2], false},
+		{"no match", keys[:3], kQueryTrans, "95", limitKey{}, true},
+		{"no limits", nil, kBackTrans, "04", limitKey{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var l = &limiter{buckets: make(map[limitKey]*tokenBucket)}
+			var buckets = make(map[*tokenBucket]limitKey)
+			for _, key := range test.keys {
+				var bucket = newTokenBucket(RateLimit{Rate: 1, Burst: 1})
+				l.buckets[key] = bucket
+				buckets[bucket] = key
+			}
+
+			var bucket = l.bucket(test.api, test.txnType)
+			if test.none {
+				if bucket != nil {
+					t.Fatalf("bucket(%s, %s) = %+v, want nil", test.api, test.txnType, buckets[bucket])
+				}
+				return
+			}
+			if got := buckets[bucket]; bucket == nil || got != test.want {
+				t.Fatalf("bucket(%s, %s) = %+v, want %+v", test.api, test.txnType, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	var start = time.Date(2026, 10, 19, 12, 0, 0, 0, kBeijing)
+
+	type step struct {
+		op   string // reserve、cancel
+		at   time.Duration
+		wait time.Duration
+	}
+
+	var tests = []struct {
+		name  string
+		limit RateLimit
+		steps []step
+	}{
+		{
+			name:  "burst",
+			limit: RateLimit{Rate: 2, Burst: 3},
+			steps: []step{
+				{op: "reserve", wait: 0},
+				{op: "reserve", wait: 0},
+				{op: "reserve", wait: 0},
+				{op: "reserve", wait: 500 * time.Millisecond},
+				{op: "reserve", wait: time.Second},
+			},
+		},
+		{
+			name:  "refill",
+			limit: RateLimit{Rate: 2, Burst: 1},
+			steps: []step{
+				{op: "reserve", wait: 0},
+				{op: "reserve", at: 250 * time.Millisecond, wait: 250 * time.Millisecond},
+				{op: "reserve", at: 2 * time.Second, wait: 0},
+			},
+		},
+		{
+			name:  "refill does not exceed burst",
+			limit: RateLimit{Rate: 10, Burst: 2},
+			steps: []step{
+				{op: "reserve", wait: 0},
+				{op: "reserve", at: time.Hour, wait: 0},
+				{op: "reserve", at: time.Hour, wait: 0},
+				{op: "reserve", at: time.Hour, wait: 100 * time.Millisecond},
+			},
+		},
+		{
+			name:  "cancel returns the token",
+			limit: RateLimit{Rate: 1, Burst: 1},
+			steps: []step{
+				{op: "reserve", wait: 0},
+				{op: "reserve", wait: time.Second},
+				{op: "cancel"},
+				{op: "reserve", wait: time.Second},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var bucket = newTokenBucket(test.limit)
+			for i, s := range test.steps {
+				switch s.op {
+				case "reserve":
+					if wait := bucket.reserve(start.Add(s.at)); wait != s.wait {
+						t.Fatalf("step %d: reserve() = %s, want %s", i, wait, s.wait)
+					}
+				case "cancel":
+					bucket.cancel(start.Add(s.at))
+				}
+			}
+		})
+	}
+}
+
+// txnType 限制对所有接口生效，等待时间超过 context 截止时间时不会请求银联。
+func TestClient_RateLimitByTxnType(t *testing.T) {
+	var pki = newTestPKI(t)
+	var requests int
+	var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		requests++
+		return testResponse(values, "34")
+	})
+
+	var reasons []string
+	var client = newTestClient(t, pki, WithGateway(server.URL), WithRateLimit("", "00", RateLimit{Rate: 0.001, Burst: 1}))
+	client.OnThrottle(func(ctx context.Context, call *Call, reason string, wait time.Duration, err error) {
+		reasons = append(reasons, reason)
+	})
+
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if transaction, err := client.GetTransaction(ctx, "20261019120000abcdef0001", "20261019120000"); err != nil || transaction.Code != CodeNotFound {
+		t.Fatalf("GetTransaction() = %v, %v, want respCode %s", transaction, err, CodeNotFound)
+	}
+	if _, err := client.GetTransaction(ctx, "20261019120000abcdef0001", "20261019120000"); !errors.Is(err, ErrThrottled) || errors.Is(err, ErrAmbiguousOutcome) {
+		t.Fatalf("GetTransaction() error = %v, want %v", err, ErrThrottled)
+	}
+	if requests != 1 {
+		t.Fatalf("requests = %d, want 1", requests)
+	}
+	if len(reasons) != 1 || reasons[0] != ThrottleReasonRateLimit {
+		t.Fatalf("throttle reasons = %v, want [%s]", reasons, ThrottleReasonRateLimit)
+	}
+}
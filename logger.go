@@ -0,0 +1,158 @@
+package unionpay
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const kRedacted = "[REDACTED]"
+
+// 需要脱敏的字段，卡号类字段只显示前 6 位和后 4 位，其它字段完全隐藏。
+var (
+	panFields = map[string]struct{}{
+		"accNo":     {},
+		"payCardNo": {},
+	}
+	sensitiveFields = map[string]struct{}{
+		"customerInfo":      {},
+		"pin":               {},
+		"cvn2":              {},
+		"expired":           {},
+		"phoneNo":           {},
+		"certifId":          {},
+		"customerNm":        {},
+		"encryptedInfo":     {},
+		"signature":         {},
+		"signPubKeyCert":    {},
+		"encryptPubKeyCert": {},
+		"cardTransData":     {},
+		"riskRateInfo":      {},
+		"reqReserved":       {},
+	}
+)
+
+// MaskPAN 对卡号进行脱敏，只显示前 6 位和后 4 位，如 621626******0018。
+func MaskPAN(pan string) string {
+	if len(pan) <= 10 {
+		return strings.Repeat("*", len(pan))
+	}
+	return pan[:6] + strings.Repeat("*", len(pan)-10) + pan[len(pan)-4:]
+}
+
+// Redact 返回脱敏之后的参数副本，用于记录日志。
+//
+// accNo、payCardNo 只显示前 6 位和后 4 位；customerInfo、pin、cvn2、encryptedInfo、signature、证书、riskRateInfo、reqReserved 等字段完全隐藏。
+func Redact(values url.Values) url.Values {
+	if values == nil {
+		return nil
+	}
+
+	var nValues = make(url.Values, len(values))
+	for key, items := range values {
+		var nItems = make([]string, len(items))
+		for i, item := range items {
+			nItems[i] = redact(key, item)
+		}
+		nValues[key] = nItems
+	}
+	return nValues
+}
+
+func redact(key, value string) string {
+	if value == "" {
+		return value
+	}
+	if _, ok := panFields[key]; ok {
+		return MaskPAN(value)
+	}
+	if _, ok := sensitiveFields[key]; ok {
+		return kRedacted
+	}
+	return value
+}
+
+// logValues 用于在日志中输出脱敏之后的参数。
+type logValues url.Values
+
+func (v logValues) LogValue() slog.Value {
+	var keys = make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var attrs = make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.String(key, redact(key, url.Values(v).Get(key))))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// WithLogger 设置日志，会记录每一次银联接口调用和通知解析的结果。
+//
+// 日志包含 endpoint、txnType、orderId、respCode、latency 等信息；Debug 级别下会额外记录脱敏之后的请求和应答参数。
+func WithLogger(logger *slog.Logger) OptionFunc {
+	return func(c *Client) {
+		if logger == nil {
+			return
+		}
+		c.logger = logger
+		c.Use(logInterceptor(logger))
+	}
+}
+
+func logInterceptor(logger *slog.Logger) Interceptor {
+	return func(ctx context.Context, call *Call, next Handler) error {
+		var err = next(ctx, call)
+
+		var attrs = []slog.Attr{
+			slog.String("endpoint", call.API),
+			slog.String("txnType", call.Values.Get("txnType")),
+			slog.String("orderId", call.Values.Get("orderId")),
+			slog.String("respCode", call.Response.Get("respCode")),
+			slog.Duration("latency", call.Latency),
+		}
+		if logger.Enabled(ctx, slog.LevelDebug) {
+			attrs = append(attrs, slog.Any("request", logValues(call.Signed)), slog.Any("response", logValues(call.Response)))
+		}
+
+		var level = slog.LevelInfo
+		switch {
+		case err != nil:
+			level = slog.LevelError
+			attrs = append(attrs, slog.String("error", err.Error()))
+		case !Code(call.Response.Get("respCode")).succeeded():
+			level = slog.LevelWarn
+			attrs = append(attrs, slog.String("respMsg", call.Response.Get("respMsg")))
+		}
+		logger.LogAttrs(ctx, level, "unionpay request", attrs...)
+		return err
+	}
+}
+
+func (c *Client) logNotification(values url.Values, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	var ctx = context.Background()
+	var attrs = []slog.Attr{
+		slog.String("txnType", values.Get("txnType")),
+		slog.String("orderId", values.Get("orderId")),
+		slog.String("queryId", values.Get("queryId")),
+		slog.String("respCode", values.Get("respCode")),
+	}
+	if c.logger.Enabled(ctx, slog.LevelDebug) {
+		attrs = append(attrs, slog.Any("notification", logValues(values)))
+	}
+
+	var level = slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	c.logger.LogAttrs(ctx, level, "unionpay notification", attrs...)
+}
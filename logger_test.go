@@ -0,0 +1,35 @@
+package unionpay
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	var values = url.Values{}
+	values.Set("accNo", "6216261000000000018")
+	values.Set("orderId", "20261019120000abcdef0001")
+	values.Set("customerInfo", "e3Bob25lTm89MTM1NTI1MzU1MDZ9")
+	values.Set("riskRateInfo", "{commodityName=测试商品&shippingMobile=13552535506}")
+	values.Set("reqReserved", "user=10086")
+	values.Set("reserved", "")
+
+	var tests = map[string]string{
+		"accNo":        "621626*********0018",
+		"orderId":      "20261019120000abcdef0001",
+		"customerInfo": kRedacted,
+		"riskRateInfo": kRedacted,
+		"reqReserved":  kRedacted,
+		"reserved":     "",
+	}
+
+	var redacted = Redact(values)
+	for key, want := range tests {
+		if got := redacted.Get(key); got != want {
+			t.Errorf("Redact()[%s] = %q, want %q", key, got, want)
+		}
+	}
+	if values.Get("riskRateInfo") == kRedacted {
+		t.Fatal("Redact() modified the original values")
+	}
+}
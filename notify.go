@@ -19,16 +19,25 @@ import (
 // *RefundNotification
 func (c *Client) DecodeNotification(values url.Values) (interface{}, error) {
 	if err := c.VerifySign(values); err != nil {
+		c.logNotification(values, err)
+		c.instrumentNotification(values, err, nil)
 		return nil, err
 	}
 
+	var notification, err = decodeNotification(values)
+	c.logNotification(values, err)
+	c.instrumentNotification(values, nil, err)
+	return notification, err
+}
+
+func decodeNotification(values url.Values) (interface{}, error) {
 	var txnType = values.Get("txnType")
 	switch txnType {
-	case "01":
+	case "01", "02", "03":
 		return DecodePaymentNotification(values)
-	case "04":
+	case "31", "32", "33":
 		return DecodeRevokeNotification(values)
-	case "31":
+	case "04":
 		return DecodeRefundNotification(values)
 	}
 
@@ -7,6 +7,9 @@ type PaymentNotification struct {
 	BizType            string `query:"bizType"`            // 产品类型
 	AccessType         string `query:"accessType"`         // 接入类型
 	AcqInsCode         string `query:"acqInsCode"`         // 收单机构代码
+	SubMerId           string `query:"subMerId"`           // 二级商户代码
+	SubMerName         string `query:"subMerName"`         // 二级商户全称
+	SubMerAbbr         string `query:"subMerAbbr"`         // 二级商户简称
 	MerId              string `query:"merId"`              // 商户代码
 	OrderId            string `query:"orderId"`            // 商户订单号
 	TxnTime            string `query:"txnTime"`            // 订单发送时间
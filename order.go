@@ -0,0 +1,548 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OrderState 订单状态。
+type OrderState int
+
+const (
+	OrderStateCreated           OrderState = iota // 已创建，等待支付结果
+	OrderStatePaid                                // 支付成功
+	OrderStateFailed                              // 支付失败
+	OrderStateRevoked                             // 已消费撤销
+	OrderStatePartiallyRefunded                   // 部分退货
+	OrderStateRefunded                            // 全额退货
+	OrderStatePreAuthorized                       // 预授权成功
+	OrderStatePreAuthCompleted                    // 预授权完成
+	OrderStatePreAuthRevoked                      // 预授权已撤销
+)
+
+func (s OrderState) String() string {
+	switch s {
+	case OrderStateCreated:
+		return "Created"
+	case OrderStatePaid:
+		return "Paid"
+	case OrderStateFailed:
+		return "Failed"
+	case OrderStateRevoked:
+		return "Revoked"
+	case OrderStatePartiallyRefunded:
+		return "PartiallyRefunded"
+	case OrderStateRefunded:
+		return "Refunded"
+	case OrderStatePreAuthorized:
+		return "PreAuthorized"
+	case OrderStatePreAuthCompleted:
+		return "PreAuthCompleted"
+	case OrderStatePreAuthRevoked:
+		return "PreAuthRevoked"
+	}
+	return "Unknown"
+}
+
+const (
+	kTxnTypeConsume             = "01"
+	kTxnTypePreAuth             = "02"
+	kTxnTypePreAuthComplete     = "03"
+	kTxnTypeRefund              = "04"
+	kTxnTypeRevoke              = "31"
+	kTxnTypePreAuthRevoke       = "32"
+	kTxnTypePreAuthCompleteUndo = "33"
+)
+
+var (
+	ErrInvalidOrderTransition = errors.New("invalid order state transition")
+	ErrRefundExceeded         = errors.New("refund amount exceeds refundable balance")
+	ErrOrderMismatch          = errors.New("transaction does not belong to the order")
+)
+
+// OrderOperation 订单上发生的一笔交易，如消费、预授权、消费撤销、退货等。
+type OrderOperation struct {
+	TxnType string           // 交易类型
+	OrderId string           // 商户订单号，消费撤销和退货为发起撤销或者退货时使用的订单号
+	TxnTime string           // 订单发送时间
+	QueryId string           // 银联交易流水号
+	Amount  int64            // 交易金额，单位分
+	State   TransactionState // 交易状态
+}
+
+// Order 订单，用于跟踪一笔消费（或预授权）订单及其后续的消费撤销、退货等交易。
+//
+// Order 会检查各操作是否合法，如：消费撤销只能在同一清算日内对全额发起、退货总金额不能超过订单金额等。
+//
+// 可以通过 Order 的 Revoke、Refund 方法发起交易，也可以通过 ApplyNotification（银联通知）和 ApplyTransaction（交易状态查询结果）更新订单状态。
+type Order struct {
+	mu         sync.Mutex
+	orderId    string
+	txnTime    string
+	amount     int64
+	queryId    string
+	state      OrderState
+	operations []*OrderOperation
+}
+
+// NewOrder 创建订单。
+//
+// orderId：商户消费订单号。
+//
+// txnTime：订单发送时间，格式为 YYYYMMDDhhmmss。
+//
+// amount：交易金额，单位分。
+func NewOrder(orderId, txnTime string, amount int64) *Order {
+	var nOrder = &Order{}
+	nOrder.orderId = orderId
+	nOrder.txnTime = txnTime
+	nOrder.amount = amount
+	nOrder.state = OrderStateCreated
+	return nOrder
+}
+
+func (o *Order) OrderId() string {
+	return o.orderId
+}
+
+func (o *Order) TxnTime() string {
+	return o.txnTime
+}
+
+func (o *Order) Amount() int64 {
+	return o.amount
+}
+
+// QueryId 返回消费（或预授权）交易的银联交易流水号，支付成功之后才有值。
+func (o *Order) QueryId() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.queryId
+}
+
+func (o *Order) State() OrderState {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.state
+}
+
+// Operations 返回订单上发生的所有交易。
+func (o *Order) Operations() []OrderOperation {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var operations = make([]OrderOperation, 0, len(o.operations))
+	for _, op := range o.operations {
+		operations = append(operations, *op)
+	}
+	return operations
+}
+
+// Refunded 返回已经退货成功的金额。
+func (o *Order) Refunded() int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.sum(kTxnTypeRefund, TransactionStateSucceeded)
+}
+
+// Refundable 返回剩余可退货金额，处理中的退货金额不可再次退货。
+func (o *Order) Refundable() int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.refundable()
+}
+
+func (o *Order) refundable() int64 {
+	if o.state != OrderStatePaid && o.state != OrderStatePartiallyRefunded {
+		return 0
+	}
+	var amount = o.amount
+	for _, op := range o.operations {
+		if op.TxnType == kTxnTypeRefund && op.State != TransactionStateFailed {
+			amount -= op.Amount
+		}
+	}
+	return amount
+}
+
+func (o *Order) sum(txnType string, state TransactionState) int64 {
+	var amount int64
+	for _, op := range o.operations {
+		if op.TxnType == txnType && op.State == state {
+			amount += op.Amount
+		}
+	}
+	return amount
+}
+
+// hasActive 判断是否存在指定类型并且没有失败的交易。
+func (o *Order) hasActive(txnType string) bool {
+	for _, op := range o.operations {
+		if op.TxnType == txnType && op.State != TransactionStateFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// CanRevoke 判断当前是否可以发起消费撤销。
+//
+// 消费撤销只能对支付成功、没有发生过退货的订单发起，并且必须与原消费交易在同一清算日内（前一日 23:00 至当日 23:00，北京时间）。
+func (o *Order) CanRevoke(now time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.canRevoke(now)
+}
+
+func (o *Order) canRevoke(now time.Time) error {
+	if o.state != OrderStatePaid {
+		return fmt.Errorf("%w: can not revoke order in state %s", ErrInvalidOrderTransition, o.state)
+	}
+	if o.hasActive(kTxnTypeRevoke) || o.hasActive(kTxnTypeRefund) {
+		return fmt.Errorf("%w: order has been revoked or refunded", ErrInvalidOrderTransition)
+	}
+
+	cutoff, err := settleCutoff(o.txnTime)
+	if err != nil {
+		return err
+	}
+	if !now.Before(cutoff) {
+		return fmt.Errorf("%w: revoke is only allowed before %s", ErrInvalidOrderTransition, cutoff.Format(time.DateTime))
+	}
+	return nil
+}
+
+// CanRefund 判断当前是否可以发起指定金额的退货。
+func (o *Order) CanRefund(amount int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.canRefund(amount)
+}
+
+func (o *Order) canRefund(amount int64) error {
+	if o.state != OrderStatePaid && o.state != OrderStatePartiallyRefunded {
+		return fmt.Errorf("%w: can not refund order in state %s", ErrInvalidOrderTransition, o.state)
+	}
+	if o.hasActive(kTxnTypeRevoke) {
+		return fmt.Errorf("%w: order has been revoked", ErrInvalidOrderTransition)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("invalid refund amount %d", amount)
+	}
+	if refundable := o.refundable(); amount > refundable {
+		return fmt.Errorf("%w: %d > %d", ErrRefundExceeded, amount, refundable)
+	}
+	return nil
+}
+
+// Revoke 对订单发起消费撤销（全额），参考 Client.Revoke。
+//
+// 发起请求之前会通过 CanRevoke 检查是否可以撤销。银联同步应答成功只表示交易已受理，交易结果需要通过 ApplyNotification 或者 ApplyTransaction 更新。
+//
+// orderId 为空时通过 client.NewOrderId 生成，以便后续可以根据 orderId 匹配通知和查询交易状态。
+func (o *Order) Revoke(ctx context.Context, client *Client, orderId, backURL string, opts ...CallOption) (*Revoke, error) {
+	if orderId == "" {
+		orderId = client.NewOrderId()
+	}
+
+	o.mu.Lock()
+	var now = client.now()
+	if err := o.canRevoke(now); err != nil {
+		o.mu.Unlock()
+		return nil, err
+	}
+	var op = o.begin(kTxnTypeRevoke, orderId, o.amount, now)
+	var queryId = o.queryId
+	o.mu.Unlock()
+
+	revoke, err := client.Revoke(ctx, queryId, orderId, strconv.FormatInt(op.Amount, 10), backURL, withTxnTime(opts, op.TxnTime)...)
+
+	o.mu.Lock()
+	if revoke != nil {
+		op.QueryId = revoke.QueryId
+		o.settle(op, revoke.Error)
+	}
+	o.mu.Unlock()
+	return revoke, err
+}
+
+// Refund 对订单发起退货，参考 Client.Refund。
+//
+// 发起请求之前会通过 CanRefund 检查是否可以退货，退货金额不能超过剩余可退货金额。银联同步应答成功只表示交易已受理，交易结果需要通过 ApplyNotification 或者 ApplyTransaction 更新。
+//
+// orderId 为空时通过 client.NewOrderId 生成，以便后续可以根据 orderId 匹配通知和查询交易状态。
+func (o *Order) Refund(ctx context.Context, client *Client, orderId string, amount int64, backURL string, opts ...CallOption) (*Refund, error) {
+	if orderId == "" {
+		orderId = client.NewOrderId()
+	}
+
+	o.mu.Lock()
+	if err := o.canRefund(amount); err != nil {
+		o.mu.Unlock()
+		return nil, err
+	}
+	var op = o.begin(kTxnTypeRefund, orderId, amount, client.now())
+	var queryId = o.queryId
+	o.mu.Unlock()
+
+	refund, err := client.Refund(ctx, queryId, orderId, strconv.FormatInt(amount, 10), backURL, withTxnTime(opts, op.TxnTime)...)
+
+	o.mu.Lock()
+	if refund != nil {
+		op.QueryId = refund.QueryId
+		o.settle(op, refund.Error)
+	}
+	o.mu.Unlock()
+	return refund, err
+}
+
+// begin 记录一笔处理中的交易，调用方需要持有 o.mu。
+func (o *Order) begin(txnType, orderId string, amount int64, now time.Time) *OrderOperation {
+	var op = &OrderOperation{}
+	op.TxnType = txnType
+	op.OrderId = orderId
+	op.TxnTime = FormatTime(now)
+	op.Amount = amount
+	op.State = TransactionStatePending
+	o.operations = append(o.operations, op)
+	return op
+}
+
+// settle 根据同步应答的应答码更新交易状态，调用方需要持有 o.mu。
+//
+// 同步应答成功只表示交易已受理；没有得到应答（网络错误等）时无法确定交易是否已被受理，不会调用本方法，交易依然作为处理中。
+func (o *Order) settle(op *OrderOperation, rErr Error) {
+	if rErr.err() != nil && !rErr.IsPending() {
+		op.State = TransactionStateFailed
+	}
+}
+
+// withTxnTime 指定交易的 txnTime，以便在无法获取应答时依然可以通过 orderId 和 txnTime 查询交易状态。
+func withTxnTime(opts []CallOption, txnTime string) []CallOption {
+	return append(opts[:len(opts):len(opts)], WithPayload(NewPayload().AddParam("txnTime", txnTime)))
+}
+
+// ApplyNotification 根据银联通知更新订单状态。
+//
+// notification 为 DecodeNotification 的返回结果：*PaymentNotification、*RevokeNotification 或者 *RefundNotification。
+func (o *Order) ApplyNotification(notification interface{}) error {
+	switch n := notification.(type) {
+	case *PaymentNotification:
+		return o.apply(n.TxnType, n.OrderId, n.TxnTime, n.QueryId, "", n.TxnAmt, notificationState(n.Code))
+	case *RevokeNotification:
+		return o.apply(n.TxnType, n.OrderId, n.TxnTime, n.QueryId, n.OrgQryId, n.TxnAmt, notificationState(n.Code))
+	case *RefundNotification:
+		return o.apply(n.TxnType, n.OrderId, n.TxnTime, n.QueryId, n.OrgQryId, n.TxnAmt, notificationState(n.Code))
+	}
+	return fmt.Errorf("unsupported notification %T", notification)
+}
+
+// ApplyTransaction 根据交易状态查询接口(GetTransaction)的结果更新订单状态。
+//
+// transaction 可以是订单本身（消费或预授权）的查询结果，也可以是通过本订单发起的消费撤销、退货等交易的查询结果。
+func (o *Order) ApplyTransaction(transaction *Transaction) error {
+	var state = transaction.State()
+	if !state.IsFinal() && state != TransactionStatePending {
+		return nil
+	}
+
+	var txnType = transaction.TxnType
+	if transaction.OrderId == o.orderId {
+		if txnType != kTxnTypePreAuth {
+			txnType = kTxnTypeConsume
+		}
+	} else {
+		o.mu.Lock()
+		if op := o.find(transaction.OrderId, transaction.TxnTime); op != nil {
+			txnType = op.TxnType
+		}
+		o.mu.Unlock()
+	}
+	return o.apply(txnType, transaction.OrderId, transaction.TxnTime, transaction.QueryId, "", transaction.TxnAmt, state)
+}
+
+func notificationState(code Code) TransactionState {
+	switch {
+	case code.succeeded():
+		return TransactionStateSucceeded
+	case code.IsPaidAfterPayTimeout():
+		return TransactionStatePaidAfterTimeout
+	case code.IsPending():
+		return TransactionStatePending
+	}
+	return TransactionStateFailed
+}
+
+func (o *Order) find(orderId, txnTime string) *OrderOperation {
+	for _, op := range o.operations {
+		if op.OrderId == orderId && (op.TxnTime == txnTime || txnTime == "" || op.TxnTime == "") {
+			return op
+		}
+	}
+	return nil
+}
+
+func (o *Order) apply(txnType, orderId, txnTime, queryId, origQryId, txnAmt string, state TransactionState) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var amount int64
+	if txnAmt != "" {
+		var err error
+		if amount, err = strconv.ParseInt(txnAmt, 10, 64); err != nil {
+			return fmt.Errorf("invalid txnAmt %s: %w", txnAmt, err)
+		}
+	}
+
+	switch txnType {
+	case kTxnTypeConsume, kTxnTypePreAuth:
+		if orderId != o.orderId || (txnTime != "" && txnTime != o.txnTime) {
+			return ErrOrderMismatch
+		}
+		return o.applyPayment(txnType, queryId, state)
+	case kTxnTypePreAuthComplete, kTxnTypeRefund, kTxnTypeRevoke, kTxnTypePreAuthRevoke, kTxnTypePreAuthCompleteUndo:
+	default:
+		return fmt.Errorf("unsupported txnType %s", txnType)
+	}
+
+	var op = o.find(orderId, txnTime)
+	if op == nil {
+		// 不是通过本对象发起的交易，根据原交易流水号判断是否属于本订单
+		if origQryId == "" || origQryId != o.queryId {
+			return ErrOrderMismatch
+		}
+		op = &OrderOperation{TxnType: txnType, OrderId: orderId, TxnTime: txnTime, Amount: amount, State: TransactionStatePending}
+		o.operations = append(o.operations, op)
+	}
+	if queryId != "" {
+		op.QueryId = queryId
+	}
+	if op.State.IsFinal() || !state.IsFinal() {
+		return nil
+	}
+	op.State = state
+	if state != TransactionStateSucceeded {
+		return nil
+	}
+
+	var from, to OrderState
+	switch op.TxnType {
+	case kTxnTypeRevoke:
+		from, to = OrderStatePaid, OrderStateRevoked
+	case kTxnTypePreAuthRevoke:
+		from, to = OrderStatePreAuthorized, OrderStatePreAuthRevoked
+	case kTxnTypePreAuthComplete:
+		from, to = OrderStatePreAuthorized, OrderStatePreAuthCompleted
+	case kTxnTypePreAuthCompleteUndo:
+		from, to = OrderStatePreAuthCompleted, OrderStatePreAuthorized
+	case kTxnTypeRefund:
+		if o.state != OrderStatePaid && o.state != OrderStatePartiallyRefunded {
+			return fmt.Errorf("%w: refund succeeded in state %s", ErrInvalidOrderTransition, o.state)
+		}
+		if o.sum(kTxnTypeRefund, TransactionStateSucceeded) >= o.amount {
+			o.state = OrderStateRefunded
+		} else {
+			o.state = OrderStatePartiallyRefunded
+		}
+		return nil
+	}
+
+	if o.state != from {
+		return fmt.Errorf("%w: %s succeeded in state %s", ErrInvalidOrderTransition, op.TxnType, o.state)
+	}
+	o.state = to
+	return nil
+}
+
+func (o *Order) applyPayment(txnType, queryId string, state TransactionState) error {
+	var to = OrderStatePaid
+	if txnType == kTxnTypePreAuth {
+		to = OrderStatePreAuthorized
+	}
+
+	switch state {
+	case TransactionStateSucceeded, TransactionStatePaidAfterTimeout:
+		// 超时扣款同样已经扣款，订单按已支付处理，由商户决定退货或者继续履约
+		if o.state == OrderStateCreated || o.state == OrderStateFailed {
+			o.state = to
+			o.queryId = queryId
+			return nil
+		}
+		if o.queryId == queryId || queryId == "" {
+			// 重复的通知
+			return nil
+		}
+		return fmt.Errorf("%w: payment succeeded in state %s", ErrInvalidOrderTransition, o.state)
+	case TransactionStateFailed:
+		if o.state == OrderStateCreated {
+			o.state = OrderStateFailed
+			return nil
+		}
+		if o.state == OrderStateFailed {
+			return nil
+		}
+		return fmt.Errorf("%w: payment failed in state %s", ErrInvalidOrderTransition, o.state)
+	}
+	return nil
+}
+
+// kNotFoundGracePeriod 交易发起之后超过该时长依然查无此交易，则认为银联没有收到该交易。
+const kNotFoundGracePeriod = 5 * time.Minute
+
+// Reconcile 通过交易状态查询接口(GetTransaction)查询订单本身（状态为 OrderStateCreated 时）以及处理中的消费撤销、退货等交易，并更新订单状态。
+//
+// 交易发起 5 分钟之后依然查无此交易的，认为银联没有收到该交易，按失败处理，其占用的可退货金额会被释放。
+func (o *Order) Reconcile(ctx context.Context, client *Client) error {
+	type pending struct {
+		txnType string
+		orderId string
+		txnTime string
+		own     bool // 订单本身（消费或预授权）的查询
+	}
+
+	var items []pending
+	o.mu.Lock()
+	if o.state == OrderStateCreated {
+		items = append(items, pending{txnType: kTxnTypeConsume, orderId: o.orderId, txnTime: o.txnTime, own: true})
+	}
+	for _, op := range o.operations {
+		if op.State == TransactionStatePending {
+			items = append(items, pending{txnType: op.TxnType, orderId: op.OrderId, txnTime: op.TxnTime})
+		}
+	}
+	o.mu.Unlock()
+
+	var now = client.now()
+	var firstErr error
+	for _, item := range items {
+		transaction, err := client.GetTransaction(ctx, item.orderId, item.txnTime)
+		var state = ResolveTransaction(transaction, err)
+
+		switch {
+		case state.IsFinal():
+			var txnType = item.txnType
+			if item.own && transaction.TxnType == kTxnTypePreAuth {
+				// 与 ApplyTransaction 一致，根据查询结果区分消费和预授权
+				txnType = kTxnTypePreAuth
+			}
+			err = o.apply(txnType, item.orderId, item.txnTime, transaction.QueryId, "", transaction.TxnAmt, state)
+		case state == TransactionStateNotFound && !item.own:
+			if sent, pErr := time.ParseInLocation(kTimeFormat, item.txnTime, kBeijing); pErr == nil && now.Sub(sent) > kNotFoundGracePeriod {
+				err = o.apply(item.txnType, item.orderId, item.txnTime, "", "", "", TransactionStateFailed)
+			} else {
+				err = nil
+			}
+		case state == TransactionStatePending || state == TransactionStateNotFound:
+			err = nil
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
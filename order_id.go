@@ -0,0 +1,112 @@
+package unionpay
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	kOrderIdMinLength = 8
+	kOrderIdMaxLength = 32
+)
+
+// ErrInvalidOrderId 商户订单号(orderId)不符合银联的要求：8-32 位字母和数字。
+var ErrInvalidOrderId = errors.New("invalid order id")
+
+// OrderIdGenerator 商户订单号生成器。
+//
+// 生成的订单号需要为 8-32 位字母和数字，并且同一商户在同一天内不能重复。
+type OrderIdGenerator interface {
+	NewOrderId(now time.Time) string
+}
+
+// OrderIdGeneratorFunc 将函数转换为 OrderIdGenerator。
+type OrderIdGeneratorFunc func(now time.Time) string
+
+func (f OrderIdGeneratorFunc) NewOrderId(now time.Time) string {
+	return f(now)
+}
+
+// WithOrderIdGenerator 设置商户订单号生成器，默认为 NewOrderIdGenerator() 的返回值。
+func WithOrderIdGenerator(generator OrderIdGenerator) OptionFunc {
+	return func(c *Client) {
+		if generator != nil {
+			c.orderIds = generator
+		}
+	}
+}
+
+// NewOrderId 生成商户订单号。
+//
+// 各交易的请求结构体（如 WebPaymentRequest、RefundRequest）中没有指定 OrderId 时，会使用本方法生成并写回请求结构体。
+func (c *Client) NewOrderId() string {
+	return c.orderIds.NewOrderId(c.now())
+}
+
+// ValidateOrderId 校验商户订单号是否为 8-32 位字母和数字。
+func ValidateOrderId(orderId string) error {
+	if len(orderId) < kOrderIdMinLength || len(orderId) > kOrderIdMaxLength {
+		return fmt.Errorf("%w: %q must be %d-%d characters", ErrInvalidOrderId, orderId, kOrderIdMinLength, kOrderIdMaxLength)
+	}
+	for i := 0; i < len(orderId); i++ {
+		var ch = orderId[i]
+		if !('0' <= ch && ch <= '9' || 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z') {
+			return fmt.Errorf("%w: %q must only contain letters and digits", ErrInvalidOrderId, orderId)
+		}
+	}
+	return nil
+}
+
+// orderIdGenerator 默认的商户订单号生成器，生成 24 位订单号：
+//
+// 14 位北京时间(YYYYMMDDhhmmss) + 6 位随机数(进程标识，base36) + 4 位自增序号(base36)。
+//
+// 同一进程内每秒可以生成 36^4 个不重复的订单号；不同进程之间通过随机数区分。
+type orderIdGenerator struct {
+	instance string
+	sequence atomic.Uint32
+}
+
+// NewOrderIdGenerator 创建默认的商户订单号生成器，参考 OrderIdGenerator。
+func NewOrderIdGenerator() OrderIdGenerator {
+	var nGenerator = &orderIdGenerator{}
+	nGenerator.instance = randomBase36(6)
+
+	var seq, _ = rand.Int(rand.Reader, big.NewInt(36*36*36*36))
+	if seq != nil {
+		nGenerator.sequence.Store(uint32(seq.Int64()))
+	}
+	return nGenerator
+}
+
+func (g *orderIdGenerator) NewOrderId(now time.Time) string {
+	var seq = g.sequence.Add(1) % (36 * 36 * 36 * 36)
+	return FormatTime(now) + g.instance + padBase36(uint64(seq), 4)
+}
+
+func randomBase36(n int) string {
+	var max = big.NewInt(1)
+	for i := 0; i < n; i++ {
+		max.Mul(max, big.NewInt(36))
+	}
+	v, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		v = big.NewInt(time.Now().UnixNano())
+		v.Mod(v, max)
+	}
+	return padBase36(v.Uint64(), n)
+}
+
+func padBase36(v uint64, n int) string {
+	var s = strconv.FormatUint(v, 36)
+	if len(s) < n {
+		s = strings.Repeat("0", n-len(s)) + s
+	}
+	return s
+}
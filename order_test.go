@@ -0,0 +1,51 @@
+package unionpay
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestOrder_Reconcile(t *testing.T) {
+	var pki = newTestPKI(t)
+	var txnTime = "20261019120000"
+	var sent, _ = ParseTxnTime(txnTime)
+
+	var tests = []struct {
+		name         string
+		txnType      string
+		origRespCode string
+		want         OrderState
+	}{
+		{"consume succeeded", kTxnTypeConsume, "00", OrderStatePaid},
+		{"consume paid after timeout", kTxnTypeConsume, "42", OrderStatePaid},
+		{"consume failed", kTxnTypeConsume, "30", OrderStateFailed},
+		{"consume pending", kTxnTypeConsume, "05", OrderStateCreated},
+		{"pre-auth succeeded", kTxnTypePreAuth, "00", OrderStatePreAuthorized},
+		{"pre-auth failed", kTxnTypePreAuth, "30", OrderStateFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+				var rValues = testResponse(values, "00")
+				rValues.Set("txnType", test.txnType)
+				rValues.Set("txnAmt", "1000")
+				rValues.Set("queryId", "123456789012345678901")
+				rValues.Set("origRespCode", test.origRespCode)
+				return rValues
+			})
+			var client = newTestClient(t, pki, WithGateway(server.URL), WithClock(ClockFunc(func() time.Time { return sent.Add(time.Minute) })))
+
+			var order = NewOrder("20261019120000abcdef0001", txnTime, 1000)
+			if err := order.Reconcile(context.Background(), client); err != nil {
+				t.Fatal(err)
+			}
+			if state := order.State(); state != test.want {
+				t.Fatalf("State() = %s, want %s", state, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+module github.com/smartwalle/unionpay/otelunionpay
+
+go 1.21
+
+require (
+	github.com/smartwalle/unionpay v0.0.6
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/metric v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/smartwalle/ncrypto v1.0.4 // indirect
+	github.com/smartwalle/ngx v1.0.12 // indirect
+	github.com/smartwalle/nhttp v0.0.10 // indirect
+	github.com/smartwalle/nsign v1.0.9 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/smartwalle/unionpay => ../
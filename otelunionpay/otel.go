@@ -0,0 +1,170 @@
+// Package otelunionpay 为 unionpay.Client 提供 OpenTelemetry 监控。
+//
+//	instrumentation, err := otelunionpay.New()
+//	client, err := unionpay.New(pfx, password, merchantId, false, unionpay.WithInstrumentation(instrumentation))
+package otelunionpay
+
+import (
+	"context"
+	"github.com/smartwalle/unionpay"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const kInstrumentationName = "github.com/smartwalle/unionpay/otelunionpay"
+
+type Option func(i *Instrumentation)
+
+// WithTracerProvider 设置 TracerProvider，默认为 otel.GetTracerProvider()。
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(i *Instrumentation) {
+		if provider != nil {
+			i.tracerProvider = provider
+		}
+	}
+}
+
+// WithMeterProvider 设置 MeterProvider，默认为 otel.GetMeterProvider()。
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(i *Instrumentation) {
+		if provider != nil {
+			i.meterProvider = provider
+		}
+	}
+}
+
+// Instrumentation 实现了 unionpay.Instrumentation 接口。
+//
+// 每次调用银联接口会创建一个 span，并记录以下指标：
+//
+// unionpay.request.duration - 接口调用耗时（秒），按 endpoint、txnType、respCode 区分。
+//
+// unionpay.signature.failures - 验签失败次数，按 source 区分。
+//
+// unionpay.notifications - 收到的通知数量，按 txnType、respCode、duplicate 区分。
+//
+// unionpay.encrypt_key.refreshes - 敏感信息加密证书更新次数，按 result 区分。
+//
+// unionpay.throttled - 被客户端限流的请求数量，按 endpoint、txnType、reason 区分。
+//
+// unionpay.throttle.wait - 因限流等待的时间（秒），按 endpoint、txnType、reason 区分。
+type Instrumentation struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+
+	tracer              trace.Tracer
+	duration            metric.Float64Histogram
+	signatureFailures   metric.Int64Counter
+	notifications       metric.Int64Counter
+	encryptKeyRefreshes metric.Int64Counter
+	throttled           metric.Int64Counter
+	throttleWait        metric.Float64Histogram
+}
+
+func New(opts ...Option) (*Instrumentation, error) {
+	var nInstrumentation = &Instrumentation{}
+	nInstrumentation.tracerProvider = otel.GetTracerProvider()
+	nInstrumentation.meterProvider = otel.GetMeterProvider()
+	for _, opt := range opts {
+		if opt != nil {
+			opt(nInstrumentation)
+		}
+	}
+
+	nInstrumentation.tracer = nInstrumentation.tracerProvider.Tracer(kInstrumentationName)
+
+	var meter = nInstrumentation.meterProvider.Meter(kInstrumentationName)
+	var err error
+	if nInstrumentation.duration, err = meter.Float64Histogram("unionpay.request.duration", metric.WithUnit("s"), metric.WithDescription("Duration of UnionPay gateway requests.")); err != nil {
+		return nil, err
+	}
+	if nInstrumentation.signatureFailures, err = meter.Int64Counter("unionpay.signature.failures", metric.WithDescription("Number of signature verification failures.")); err != nil {
+		return nil, err
+	}
+	if nInstrumentation.notifications, err = meter.Int64Counter("unionpay.notifications", metric.WithDescription("Number of UnionPay notifications received.")); err != nil {
+		return nil, err
+	}
+	if nInstrumentation.encryptKeyRefreshes, err = meter.Int64Counter("unionpay.encrypt_key.refreshes", metric.WithDescription("Number of encryption certificate refreshes.")); err != nil {
+		return nil, err
+	}
+	if nInstrumentation.throttled, err = meter.Int64Counter("unionpay.throttled", metric.WithDescription("Number of requests throttled by the client-side limiter.")); err != nil {
+		return nil, err
+	}
+	if nInstrumentation.throttleWait, err = meter.Float64Histogram("unionpay.throttle.wait", metric.WithUnit("s"), metric.WithDescription("Time spent waiting for the client-side limiter.")); err != nil {
+		return nil, err
+	}
+	return nInstrumentation, nil
+}
+
+func (i *Instrumentation) StartRequest(ctx context.Context, call *unionpay.Call) (context.Context, func(call *unionpay.Call, err error)) {
+	var start = time.Now()
+	ctx, span := i.tracer.Start(ctx, "unionpay "+call.API,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("unionpay.endpoint", call.API),
+			attribute.String("unionpay.txn_type", call.Values.Get("txnType")),
+			attribute.String("unionpay.biz_type", call.Values.Get("bizType")),
+			attribute.String("unionpay.order_id", call.Values.Get("orderId")),
+		),
+	)
+
+	return ctx, func(call *unionpay.Call, err error) {
+		var respCode = call.Response.Get("respCode")
+		span.SetAttributes(attribute.String("unionpay.resp_code", respCode))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		i.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+			attribute.String("endpoint", call.API),
+			attribute.String("txn_type", call.Values.Get("txnType")),
+			attribute.String("resp_code", respCode),
+			attribute.Bool("error", err != nil),
+		))
+	}
+}
+
+func (i *Instrumentation) SignatureFailure(ctx context.Context, source string, err error) {
+	i.signatureFailures.Add(ctx, 1, metric.WithAttributes(attribute.String("source", source)))
+}
+
+func (i *Instrumentation) Notification(ctx context.Context, values url.Values, duplicate bool, err error) {
+	i.notifications.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("txn_type", values.Get("txnType")),
+		attribute.String("resp_code", values.Get("respCode")),
+		attribute.String("duplicate", strconv.FormatBool(duplicate)),
+		attribute.Bool("error", err != nil),
+	))
+}
+
+func (i *Instrumentation) EncryptKeyRefresh(ctx context.Context, certId string, err error) {
+	var result = "success"
+	if err != nil {
+		result = "failure"
+	}
+	i.encryptKeyRefreshes.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+}
+
+func (i *Instrumentation) Throttled(ctx context.Context, call *unionpay.Call, reason string, wait time.Duration, err error) {
+	var attrs = metric.WithAttributes(
+		attribute.String("endpoint", call.API),
+		attribute.String("txn_type", call.Values.Get("txnType")),
+		attribute.String("reason", reason),
+		attribute.Bool("error", err != nil),
+	)
+	i.throttled.Add(ctx, 1, attrs)
+	i.throttleWait.Record(ctx, wait.Seconds(), attrs)
+
+	trace.SpanFromContext(ctx).AddEvent("unionpay.throttled", trace.WithAttributes(
+		attribute.String("unionpay.throttle_reason", reason),
+		attribute.Float64("unionpay.throttle_wait", wait.Seconds()),
+	))
+}
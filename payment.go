@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"net/url"
-	"time"
 )
 
 const (
@@ -18,7 +17,7 @@ const (
 //
 // 文档地址：https://open.unionpay.com/tjweb/acproduct/APIList?acpAPIId=754&apiservId=448&version=V2.2&bussType=0
 //
-// orderId：商户消费订单号。
+// orderId：商户消费订单号，8-32 位字母和数字，为空时自动生成。
 //
 // amount：交易金额，单位分，不要带小数点。
 //
@@ -26,25 +25,51 @@ const (
 //
 // backURL：后台通知地址。
 func (c *Client) CreateWebPayment(ctx context.Context, orderId, amount, frontURL, backURL string, opts ...CallOption) (*WebPayment, error) {
+	var req = &WebPaymentRequest{}
+	req.OrderId = orderId
+	req.TxnAmt = amount
+	req.FrontURL = frontURL
+	req.BackURL = backURL
+	return c.CreateWebPaymentWith(ctx, req, opts...)
+}
+
+// CreateWebPaymentWith 消费接口-创建网页支付，参考 CreateWebPayment。
+//
+// req.OrderId 为空时会通过 NewOrderId 生成并写回 req。
+//
+// req 中非空的字段会替换 WithPayload() 设置的同名参数，之后会校验最终的请求参数（包括 WithPayload() 设置的参数），校验失败时返回 ValidationError。
+func (c *Client) CreateWebPaymentWith(ctx context.Context, req *WebPaymentRequest, opts ...CallOption) (*WebPayment, error) {
+	if req.OrderId == "" {
+		req.OrderId = c.NewOrderId()
+	}
+
 	var values = url.Values{}
 	// 此处的参数可被 WithPayload() 替换
-	values.Set("accessType", "0")
+	values.Set("accessType", c.accessType)
 	values.Set("currencyCode", "156") // 交易币种 156 - 人民币
 	values.Set("channelType", "07")   // 渠道类型，这个字段区分B2C网关支付和手机wap支付；07 - PC,平板  08 - 手机
 	values.Set("bizType", "000201")   // 业务类型，000201 - B2C网关支付和手机wap支付
 	values.Set("txnType", "01")
 	values.Set("txnSubType", "01") // 01：自助消费，通过地址的方式区分前台消费和后台消费（含无跳转支付） 03：分期付款
-	values.Set("txnTime", time.Now().Format("20060102150405"))
+	values.Set("txnTime", c.txnTime())
 	for _, opt := range opts {
 		if opt != nil {
 			opt(values)
 		}
 	}
 
-	values.Set("orderId", orderId)
-	values.Set("txnAmt", amount)
-	values.Set("frontUrl", frontURL)
-	values.Set("backUrl", backURL)
+	if err := EncodeRequest(values, req); err != nil {
+		return nil, err
+	}
+	if err := validateRequest(values, req); err != nil {
+		return nil, err
+	}
+	if err := encodeAccSplitData(values, req.AccSplitData); err != nil {
+		return nil, err
+	}
+	if err := c.applyPayTimeout(values, req.PayTimeout); err != nil {
+		return nil, err
+	}
 
 	values, err := c.URLValues(values)
 	if err != nil {
@@ -65,39 +90,72 @@ func (c *Client) CreateWebPayment(ctx context.Context, orderId, amount, frontURL
 	payment.TxnType = values.Get("txnType")
 	payment.TxnSubType = values.Get("txnSubType")
 	payment.AccessType = values.Get("accessType")
+	payment.AcqInsCode = values.Get("acqInsCode")
+	payment.SubMerId = values.Get("subMerId")
+	payment.SubMerName = values.Get("subMerName")
+	payment.SubMerAbbr = values.Get("subMerAbbr")
 	payment.MerId = values.Get("merId")
 	payment.OrderId = values.Get("orderId")
+	payment.PayTimeout = values.Get("payTimeout")
 	return payment, nil
 }
 
 // CreateAppPayment 消费接口-创建 App 支付。
 //
+// 应答码(respCode)表示失败时依然返回结果和 nil，需要通过结果中的 Error 判断交易是否成功。
+//
 // 文档地址：https://open.unionpay.com/tjweb/acproduct/APIList?apiservId=3021&acpAPIId=961&bussType=0
 //
-// orderId：商户消费订单号。
+// orderId：商户消费订单号，8-32 位字母和数字，为空时自动生成。
 //
 // amount：交易金额，单位分，不要带小数点。
 //
 // backURL：后台通知地址。
 func (c *Client) CreateAppPayment(ctx context.Context, orderId, amount, backURL string, opts ...CallOption) (*AppPayment, error) {
+	var req = &AppPaymentRequest{}
+	req.OrderId = orderId
+	req.TxnAmt = amount
+	req.BackURL = backURL
+	return c.CreateAppPaymentWith(ctx, req, opts...)
+}
+
+// CreateAppPaymentWith 消费接口-创建 App 支付，参考 CreateAppPayment。
+//
+// req.OrderId 为空时会通过 NewOrderId 生成并写回 req。
+//
+// req 中非空的字段会替换 WithPayload() 设置的同名参数，之后会校验最终的请求参数（包括 WithPayload() 设置的参数），校验失败时返回 ValidationError。
+func (c *Client) CreateAppPaymentWith(ctx context.Context, req *AppPaymentRequest, opts ...CallOption) (*AppPayment, error) {
+	if req.OrderId == "" {
+		req.OrderId = c.NewOrderId()
+	}
+
 	var values = url.Values{}
 	// 此处的参数可被 WithPayload() 替换
-	values.Set("accessType", "0")
+	values.Set("accessType", c.accessType)
 	values.Set("currencyCode", "156") // 交易币种 156 - 人民币
 	values.Set("channelType", "08")   // 渠道类型，这个字段区分B2C网关支付和手机wap支付；07 - PC,平板  08 - 手机
 	values.Set("bizType", "000201")   // 业务类型，000201 - B2C网关支付和手机wap支付
 	values.Set("txnType", "01")
 	values.Set("txnSubType", "01") // 01：自助消费，通过地址的方式区分前台消费和后台消费（含无跳转支付） 03：分期付款
-	values.Set("txnTime", time.Now().Format("20060102150405"))
+	values.Set("txnTime", c.txnTime())
 	for _, opt := range opts {
 		if opt != nil {
 			opt(values)
 		}
 	}
 
-	values.Set("orderId", orderId)
-	values.Set("txnAmt", amount)
-	values.Set("backUrl", backURL)
+	if err := EncodeRequest(values, req); err != nil {
+		return nil, err
+	}
+	if err := validateRequest(values, req); err != nil {
+		return nil, err
+	}
+	if err := encodeAccSplitData(values, req.AccSplitData); err != nil {
+		return nil, err
+	}
+	if err := c.applyPayTimeout(values, req.PayTimeout); err != nil {
+		return nil, err
+	}
 
 	var rValues, err = c.Request(ctx, kAppTrans, values)
 	if err != nil {
@@ -113,6 +171,8 @@ func (c *Client) CreateAppPayment(ctx context.Context, orderId, amount, backURL
 
 // GetTransaction 交易状态查询接口
 //
+// 应答码(respCode)表示失败时依然返回结果和 nil，需要通过结果中的 Error 判断交易是否成功。
+//
 // 文档地址：https://open.unionpay.com/tjweb/acproduct/APIList?acpAPIId=757&apiservId=448&version=V2.2&bussType=0
 //
 // orderId：商户订单号。
@@ -123,9 +183,19 @@ func (c *Client) CreateAppPayment(ctx context.Context, orderId, amount, backURL
 // 应答报文中，“应答码”即respCode字段，表示的是查询交易本身的应答，即查询这个动作是否成功，不代表被查询交易的状态；
 // 若查询动作成功，即应答码为“00“，则根据“原交易应答码”即origRespCode来判断被查询交易是否成功。此时若origRespCode为00，则表示被查询交易成功。
 func (c *Client) GetTransaction(ctx context.Context, orderId, txnTime string, opts ...CallOption) (*Transaction, error) {
+	var req = &QueryRequest{}
+	req.OrderId = orderId
+	req.TxnTime = txnTime
+	return c.GetTransactionWith(ctx, req, opts...)
+}
+
+// GetTransactionWith 交易状态查询接口，参考 GetTransaction。
+//
+// req 中非空的字段会替换 WithPayload() 设置的同名参数，之后会校验最终的请求参数（包括 WithPayload() 设置的参数），校验失败时返回 ValidationError。
+func (c *Client) GetTransactionWith(ctx context.Context, req *QueryRequest, opts ...CallOption) (*Transaction, error) {
 	var values = url.Values{}
 	// 此处的参数可被 WithPayload() 替换
-	values.Set("accessType", "0")
+	values.Set("accessType", c.accessType)
 	values.Set("bizType", "000000")
 	values.Set("txnType", "00")
 	values.Set("txnSubType", "00")
@@ -135,8 +205,12 @@ func (c *Client) GetTransaction(ctx context.Context, orderId, txnTime string, op
 		}
 	}
 
-	values.Set("orderId", orderId)
-	values.Set("txnTime", txnTime)
+	if err := EncodeRequest(values, req); err != nil {
+		return nil, err
+	}
+	if err := validateRequest(values, req); err != nil {
+		return nil, err
+	}
 
 	var rValues, err = c.Request(ctx, kQueryTrans, values)
 	if err != nil {
@@ -152,11 +226,13 @@ func (c *Client) GetTransaction(ctx context.Context, orderId, txnTime string, op
 
 // Revoke 消费撤销接口。
 //
+// 应答码(respCode)表示失败时依然返回结果和 nil，需要通过结果中的 Error 判断交易是否成功。
+//
 // 文档地址：https://open.unionpay.com/tjweb/acproduct/APIList?acpAPIId=755&apiservId=448&version=V2.2&bussType=0
 //
 // queryId：原消费交易返回的的queryId，可以从消费交易后台通知接口中或者交易状态查询接口(GetTransaction)中获取。
 //
-// orderId：商户撤销订单号，和要消费撤销的订单号没有关系。后续可用本 orderId 和返回结构体中的 TxnTime 通过交易状态查询接口(GetTransaction) 查询消费撤销信息。
+// orderId：商户撤销订单号，8-32 位字母和数字，为空时自动生成，和要消费撤销的订单号没有关系。后续可用本 orderId 和返回结构体中的 TxnTime 通过交易状态查询接口(GetTransaction) 查询消费撤销信息。
 //
 // amount：退货金额，单位分，不要带小数点。
 //
@@ -174,25 +250,45 @@ func (c *Client) GetTransaction(ctx context.Context, orderId, txnTime string, op
 //
 // 注2：系统实际支持330天的退货，但银联对发卡行的退货支持要求仅为90天，超过90天的退货发卡行虽然也会承兑，但可能为人工处理，到账速度较慢。330天以上的退货也可能成功，但不保证一定可以成功（失败应该会同步报错4040007之类的应答码），建议直接给用户转账来退款。
 func (c *Client) Revoke(ctx context.Context, queryId, orderId, amount, backURL string, opts ...CallOption) (*Revoke, error) {
+	var req = &RevokeRequest{}
+	req.OrigQryId = queryId
+	req.OrderId = orderId
+	req.TxnAmt = amount
+	req.BackURL = backURL
+	return c.RevokeWith(ctx, req, opts...)
+}
+
+// RevokeWith 消费撤销接口，参考 Revoke。
+//
+// req.OrderId 为空时会通过 NewOrderId 生成并写回 req。
+//
+// req 中非空的字段会替换 WithPayload() 设置的同名参数，之后会校验最终的请求参数（包括 WithPayload() 设置的参数），校验失败时返回 ValidationError。
+func (c *Client) RevokeWith(ctx context.Context, req *RevokeRequest, opts ...CallOption) (*Revoke, error) {
+	if req.OrderId == "" {
+		req.OrderId = c.NewOrderId()
+	}
+
 	var values = url.Values{}
 	// 此处的参数可被 WithPayload() 替换
-	values.Set("accessType", "0")
+	values.Set("accessType", c.accessType)
 	values.Set("currencyCode", "156") // 交易币种 156 - 人民币
 	values.Set("channelType", "07")   // 渠道类型，这个字段区分B2C网关支付和手机wap支付；07 - PC,平板  08 - 手机
 	values.Set("bizType", "000201")   // 业务类型，000201 - B2C网关支付和手机wap支付
 	values.Set("txnType", "31")
 	values.Set("txnSubType", "00")
-	values.Set("txnTime", time.Now().Format("20060102150405"))
+	values.Set("txnTime", c.txnTime())
 	for _, opt := range opts {
 		if opt != nil {
 			opt(values)
 		}
 	}
 
-	values.Set("origQryId", queryId)
-	values.Set("orderId", orderId)
-	values.Set("txnAmt", amount)
-	values.Set("backUrl", backURL)
+	if err := EncodeRequest(values, req); err != nil {
+		return nil, err
+	}
+	if err := validateRequest(values, req); err != nil {
+		return nil, err
+	}
 
 	var rValues, err = c.Request(ctx, kBackTrans, values)
 	if err != nil {
@@ -208,11 +304,13 @@ func (c *Client) Revoke(ctx context.Context, queryId, orderId, amount, backURL s
 
 // Refund 退货接口。
 //
+// 应答码(respCode)表示失败时依然返回结果和 nil，需要通过结果中的 Error 判断交易是否成功。
+//
 // 文档地址：https://open.unionpay.com/tjweb/acproduct/APIList?acpAPIId=756&apiservId=448&version=V2.2&bussType=0
 //
 // queryId：原消费交易返回的的queryId，可以从消费交易后台通知接口中或者交易状态查询接口(GetTransaction)中获取。
 //
-// orderId：商户退货订单号，和要退款的订单号没有关系。后续可用本 orderId 和返回结构体中的 TxnTime 通过交易状态查询接口(GetTransaction) 查询退货信息。
+// orderId：商户退货订单号，8-32 位字母和数字，为空时自动生成，和要退款的订单号没有关系。后续可用本 orderId 和返回结构体中的 TxnTime 通过交易状态查询接口(GetTransaction) 查询退货信息。
 //
 // amount：退货金额，单位分，不要带小数点。
 //
@@ -230,26 +328,45 @@ func (c *Client) Revoke(ctx context.Context, queryId, orderId, amount, backURL s
 //
 // 注2：系统实际支持330天的退货，但银联对发卡行的退货支持要求仅为90天，超过90天的退货发卡行虽然也会承兑，但可能为人工处理，到账速度较慢。330天以上的退货也可能成功，但不保证一定可以成功（失败应该会同步报错4040007之类的应答码），建议直接给用户转账来退款。
 func (c *Client) Refund(ctx context.Context, queryId, orderId, amount, backURL string, opts ...CallOption) (*Refund, error) {
-	var values = url.Values{}
+	var req = &RefundRequest{}
+	req.OrigQryId = queryId
+	req.OrderId = orderId
+	req.TxnAmt = amount
+	req.BackURL = backURL
+	return c.RefundWith(ctx, req, opts...)
+}
 
+// RefundWith 退货接口，参考 Refund。
+//
+// req.OrderId 为空时会通过 NewOrderId 生成并写回 req。
+//
+// req 中非空的字段会替换 WithPayload() 设置的同名参数，之后会校验最终的请求参数（包括 WithPayload() 设置的参数），校验失败时返回 ValidationError。
+func (c *Client) RefundWith(ctx context.Context, req *RefundRequest, opts ...CallOption) (*Refund, error) {
+	if req.OrderId == "" {
+		req.OrderId = c.NewOrderId()
+	}
+
+	var values = url.Values{}
 	// 此处的参数可被 WithPayload() 替换
-	values.Set("accessType", "0")
+	values.Set("accessType", c.accessType)
 	values.Set("currencyCode", "156") // 交易币种 156 - 人民币
 	values.Set("channelType", "07")   // 渠道类型，这个字段区分B2C网关支付和手机wap支付；07 - PC,平板  08 - 手机
 	values.Set("bizType", "000201")   // 业务类型，000201 - B2C网关支付和手机wap支付
 	values.Set("txnType", "04")
 	values.Set("txnSubType", "00")
-	values.Set("txnTime", time.Now().Format("20060102150405"))
+	values.Set("txnTime", c.txnTime())
 	for _, opt := range opts {
 		if opt != nil {
 			opt(values)
 		}
 	}
 
-	values.Set("origQryId", queryId)
-	values.Set("orderId", orderId)
-	values.Set("txnAmt", amount)
-	values.Set("backUrl", backURL)
+	if err := EncodeRequest(values, req); err != nil {
+		return nil, err
+	}
+	if err := validateRequest(values, req); err != nil {
+		return nil, err
+	}
 
 	var rValues, err = c.Request(ctx, kBackTrans, values)
 	if err != nil {
@@ -59,8 +59,13 @@ type WebPayment struct {
 	TxnType    string // 交易类型
 	TxnSubType string // 交易子类
 	AccessType string // 接入类型
+	AcqInsCode string // 收单机构代码
+	SubMerId   string // 二级商户代码
+	SubMerName string // 二级商户全称
+	SubMerAbbr string // 二级商户简称
 	MerId      string // 商户代码
 	OrderId    string // 商户订单号
+	PayTimeout string // 订单支付超时时间
 }
 
 type AppPayment struct {
@@ -73,6 +78,9 @@ type AppPayment struct {
 	TxnType     string `query:"txnType"`     // 交易类型
 	TxnSubType  string `query:"txnSubType"`  // 交易子类
 	AccessType  string `query:"accessType"`  // 接入类型
+	SubMerId    string `query:"subMerId"`    // 二级商户代码
+	SubMerName  string `query:"subMerName"`  // 二级商户全称
+	SubMerAbbr  string `query:"subMerAbbr"`  // 二级商户简称
 	ReqReserved string `query:"reqReserved"` // 请求方保留域
 	MerId       string `query:"merId"`       // 商户代码
 	OrderId     string `query:"orderId"`     // 商户订单号
@@ -112,8 +120,12 @@ type Transaction struct {
 	Reserved           string `query:"reserved"`           // 保留域
 	ReqReserved        string `query:"reqReserved"`        // 请求方保留域
 	AcqInsCode         string `query:"acqInsCode"`         // 收单机构代码
+	SubMerId           string `query:"subMerId"`           // 二级商户代码
+	SubMerName         string `query:"subMerName"`         // 二级商户全称
+	SubMerAbbr         string `query:"subMerAbbr"`         // 二级商户简称
 	PreAuthId          string `query:"preAuthId"`          // 预授权号
 	InstalTransInfo    string `query:"instalTransInfo"`    // 分期付款信息域
+	AccSplitData       string `query:"accSplitData"`       // 分账域
 }
 
 type Revoke struct {
@@ -123,6 +135,9 @@ type Revoke struct {
 	BizType     string `query:"bizType"`     // 产品类型
 	AccessType  string `query:"accessType"`  // 接入类型
 	AcqInsCode  string `query:"acqInsCode"`  // 收单机构代码
+	SubMerId    string `query:"subMerId"`    // 二级商户代码
+	SubMerName  string `query:"subMerName"`  // 二级商户全称
+	SubMerAbbr  string `query:"subMerAbbr"`  // 二级商户简称
 	MerId       string `query:"merId"`       // 商户代码
 	OrderId     string `query:"orderId"`     // 商户消费撤销订单号
 	OrgQryId    string `query:"origQryId"`   // 原始交易流水号
@@ -141,6 +156,9 @@ type Refund struct {
 	BizType     string `query:"bizType"`     // 产品类型
 	AccessType  string `query:"accessType"`  // 接入类型
 	AcqInsCode  string `query:"acqInsCode"`  // 收单机构代码
+	SubMerId    string `query:"subMerId"`    // 二级商户代码
+	SubMerName  string `query:"subMerName"`  // 二级商户全称
+	SubMerAbbr  string `query:"subMerAbbr"`  // 二级商户简称
 	MerId       string `query:"merId"`       // 商户代码
 	OrderId     string `query:"orderId"`     // 商户退货订单号
 	OrgQryId    string `query:"origQryId"`   // 原始交易流水号
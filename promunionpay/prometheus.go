@@ -0,0 +1,125 @@
+// Package promunionpay 为 unionpay.Client 提供 Prometheus 监控。
+//
+//	instrumentation, err := promunionpay.New(prometheus.DefaultRegisterer)
+//	client, err := unionpay.New(pfx, password, merchantId, false, unionpay.WithInstrumentation(instrumentation))
+package promunionpay
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/smartwalle/unionpay"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const kNamespace = "unionpay"
+
+// Instrumentation 实现了 unionpay.Instrumentation 接口，记录以下指标：
+//
+// unionpay_request_duration_seconds - 接口调用耗时，按 endpoint、txn_type、resp_code、result 区分。
+//
+// unionpay_signature_failures_total - 验签失败次数，按 source 区分。
+//
+// unionpay_notifications_total - 收到的通知数量，按 txn_type、resp_code、duplicate 区分。
+//
+// unionpay_encrypt_key_refreshes_total - 敏感信息加密证书更新次数，按 result 区分。
+//
+// unionpay_throttled_total - 被客户端限流的请求数量，按 endpoint、txn_type、reason、result 区分。
+//
+// unionpay_throttle_wait_seconds - 因限流等待的时间，按 endpoint、txn_type、reason 区分。
+type Instrumentation struct {
+	duration            *prometheus.HistogramVec
+	signatureFailures   *prometheus.CounterVec
+	notifications       *prometheus.CounterVec
+	encryptKeyRefreshes *prometheus.CounterVec
+	throttled           *prometheus.CounterVec
+	throttleWait        *prometheus.HistogramVec
+}
+
+// New 创建 Instrumentation 并将各指标注册到 registerer，registerer 为 nil 时使用 prometheus.DefaultRegisterer。
+func New(registerer prometheus.Registerer) (*Instrumentation, error) {
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	var nInstrumentation = &Instrumentation{}
+	nInstrumentation.duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: kNamespace,
+		Name:      "request_duration_seconds",
+		Help:      "Duration of UnionPay gateway requests.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30},
+	}, []string{"endpoint", "txn_type", "resp_code", "result"})
+	nInstrumentation.signatureFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: kNamespace,
+		Name:      "signature_failures_total",
+		Help:      "Number of signature verification failures.",
+	}, []string{"source"})
+	nInstrumentation.notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: kNamespace,
+		Name:      "notifications_total",
+		Help:      "Number of UnionPay notifications received.",
+	}, []string{"txn_type", "resp_code", "duplicate", "result"})
+	nInstrumentation.encryptKeyRefreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: kNamespace,
+		Name:      "encrypt_key_refreshes_total",
+		Help:      "Number of encryption certificate refreshes.",
+	}, []string{"result"})
+	nInstrumentation.throttled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: kNamespace,
+		Name:      "throttled_total",
+		Help:      "Number of requests throttled by the client-side limiter.",
+	}, []string{"endpoint", "txn_type", "reason", "result"})
+	nInstrumentation.throttleWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: kNamespace,
+		Name:      "throttle_wait_seconds",
+		Help:      "Time spent waiting for the client-side limiter.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10},
+	}, []string{"endpoint", "txn_type", "reason"})
+
+	for _, collector := range []prometheus.Collector{
+		nInstrumentation.duration,
+		nInstrumentation.signatureFailures,
+		nInstrumentation.notifications,
+		nInstrumentation.encryptKeyRefreshes,
+		nInstrumentation.throttled,
+		nInstrumentation.throttleWait,
+	} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return nInstrumentation, nil
+}
+
+func result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+func (i *Instrumentation) StartRequest(ctx context.Context, call *unionpay.Call) (context.Context, func(call *unionpay.Call, err error)) {
+	var start = time.Now()
+	return ctx, func(call *unionpay.Call, err error) {
+		i.duration.WithLabelValues(call.API, call.Values.Get("txnType"), call.Response.Get("respCode"), result(err)).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (i *Instrumentation) SignatureFailure(ctx context.Context, source string, err error) {
+	i.signatureFailures.WithLabelValues(source).Inc()
+}
+
+func (i *Instrumentation) Notification(ctx context.Context, values url.Values, duplicate bool, err error) {
+	i.notifications.WithLabelValues(values.Get("txnType"), values.Get("respCode"), strconv.FormatBool(duplicate), result(err)).Inc()
+}
+
+func (i *Instrumentation) EncryptKeyRefresh(ctx context.Context, certId string, err error) {
+	i.encryptKeyRefreshes.WithLabelValues(result(err)).Inc()
+}
+
+func (i *Instrumentation) Throttled(ctx context.Context, call *unionpay.Call, reason string, wait time.Duration, err error) {
+	var txnType = call.Values.Get("txnType")
+	i.throttled.WithLabelValues(call.API, txnType, reason, result(err)).Inc()
+	i.throttleWait.WithLabelValues(call.API, txnType, reason).Observe(wait.Seconds())
+}
@@ -0,0 +1,182 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrOriginNotTracked 原消费交易没有在 RefundLedger 中登记。
+var ErrOriginNotTracked = errors.New("original transaction is not tracked")
+
+// RefundLedger 退货台账，以原消费交易的银联交易流水号(queryId)为单位记录多次（部分）退货。
+//
+// 通过 RefundLedger 发起的退货会先在本地检查剩余可退货金额，超出时直接返回 ErrRefundExceeded，不会请求银联。
+//
+// 处理中的退货可以通过 ApplyNotification（退货通知）或者 Reconcile（交易状态查询）更新状态。
+type RefundLedger struct {
+	client *Client
+
+	mu     sync.Mutex
+	orders map[string]*Order
+}
+
+func NewRefundLedger(client *Client) *RefundLedger {
+	var nLedger = &RefundLedger{}
+	nLedger.client = client
+	nLedger.orders = make(map[string]*Order)
+	return nLedger
+}
+
+// Track 登记一笔已经支付成功的原消费交易，如果该交易已经登记，则返回已登记的 Order（此时忽略 refunds）。
+//
+// queryId：原消费交易的银联交易流水号。
+//
+// orderId、txnTime：原消费交易的商户订单号和订单发送时间。
+//
+// amount：原消费交易的金额，单位分。
+//
+// refunds：原消费交易上已经发起过的退货，如从数据库中加载的退货记录，用于计算剩余可退货金额。
+// TxnType 可以为空；State 为成功、失败或者处理中，其它状态均按处理中登记，处理中的退货需要 OrderId 和 TxnTime 才能通过 Reconcile 更新状态。
+// 没有失败的退货金额之和超过 amount 时返回 ErrRefundExceeded。
+func (l *RefundLedger) Track(queryId, orderId, txnTime string, amount int64, refunds ...OrderOperation) (*Order, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if order, ok := l.orders[queryId]; ok {
+		return order, nil
+	}
+
+	var order = NewOrder(orderId, txnTime, amount)
+	order.state = OrderStatePaid
+	order.queryId = queryId
+
+	var refunded, occupied int64
+	for i := range refunds {
+		var refund = refunds[i]
+		if refund.TxnType != "" && refund.TxnType != kTxnTypeRefund {
+			return nil, fmt.Errorf("unexpected txnType %s of refund %s", refund.TxnType, refund.OrderId)
+		}
+		if refund.Amount <= 0 {
+			return nil, fmt.Errorf("invalid amount %d of refund %s", refund.Amount, refund.OrderId)
+		}
+		refund.TxnType = kTxnTypeRefund
+		switch refund.State {
+		case TransactionStateSucceeded:
+			refunded += refund.Amount
+		case TransactionStateFailed:
+		default:
+			refund.State = TransactionStatePending
+		}
+		if refund.State != TransactionStateFailed {
+			occupied += refund.Amount
+		}
+		order.operations = append(order.operations, &refund)
+	}
+	if occupied > amount {
+		return nil, fmt.Errorf("%w: %d > %d", ErrRefundExceeded, occupied, amount)
+	}
+	if refunded >= amount {
+		order.state = OrderStateRefunded
+	} else if refunded > 0 {
+		order.state = OrderStatePartiallyRefunded
+	}
+
+	l.orders[queryId] = order
+	return order, nil
+}
+
+// TrackOrder 登记一个已经支付成功的 Order。
+func (l *RefundLedger) TrackOrder(order *Order) error {
+	var queryId = order.QueryId()
+	if queryId == "" {
+		return fmt.Errorf("%w: order %s has not been paid", ErrInvalidOrderTransition, order.OrderId())
+	}
+
+	l.mu.Lock()
+	l.orders[queryId] = order
+	l.mu.Unlock()
+	return nil
+}
+
+// Order 返回原消费交易对应的 Order，没有登记时返回 nil。
+func (l *RefundLedger) Order(queryId string) *Order {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.orders[queryId]
+}
+
+func (l *RefundLedger) order(queryId string) (*Order, error) {
+	var order = l.Order(queryId)
+	if order == nil {
+		return nil, fmt.Errorf("%w: %s", ErrOriginNotTracked, queryId)
+	}
+	return order, nil
+}
+
+// Refunds 返回原消费交易上的所有退货记录（包括处理中和失败的退货）。
+func (l *RefundLedger) Refunds(queryId string) ([]OrderOperation, error) {
+	order, err := l.order(queryId)
+	if err != nil {
+		return nil, err
+	}
+
+	var refunds []OrderOperation
+	for _, op := range order.Operations() {
+		if op.TxnType == kTxnTypeRefund {
+			refunds = append(refunds, op)
+		}
+	}
+	return refunds, nil
+}
+
+// Remaining 返回原消费交易剩余可退货金额，处理中的退货金额不计入剩余可退货金额。
+func (l *RefundLedger) Remaining(queryId string) (int64, error) {
+	order, err := l.order(queryId)
+	if err != nil {
+		return 0, err
+	}
+	return order.Refundable(), nil
+}
+
+// Refund 对原消费交易发起退货，参考 Client.Refund。
+//
+// 退货金额超过剩余可退货金额时返回 ErrRefundExceeded，不会请求银联；orderId 为空时通过 Client.NewOrderId 生成。
+func (l *RefundLedger) Refund(ctx context.Context, queryId, orderId string, amount int64, backURL string, opts ...CallOption) (*Refund, error) {
+	order, err := l.order(queryId)
+	if err != nil {
+		return nil, err
+	}
+	return order.Refund(ctx, l.client, orderId, amount, backURL, opts...)
+}
+
+// ApplyNotification 根据退货通知更新退货状态。
+func (l *RefundLedger) ApplyNotification(notification *RefundNotification) error {
+	order, err := l.order(notification.OrgQryId)
+	if err != nil {
+		return err
+	}
+	return order.ApplyNotification(notification)
+}
+
+// Reconcile 查询所有处理中的退货并更新其状态，参考 Order.Reconcile。
+func (l *RefundLedger) Reconcile(ctx context.Context) error {
+	l.mu.Lock()
+	var orders = make([]*Order, 0, len(l.orders))
+	for _, order := range l.orders {
+		orders = append(orders, order)
+	}
+	l.mu.Unlock()
+
+	var firstErr error
+	for _, order := range orders {
+		if err := order.Reconcile(ctx, l.client); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,187 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRefundLedger_Track(t *testing.T) {
+	var tests = []struct {
+		name      string
+		refunds   []OrderOperation
+		state     OrderState
+		remaining int64
+		err       error
+	}{
+		{"no refunds", nil, OrderStatePaid, 1000, nil},
+		{"partially refunded", []OrderOperation{{OrderId: "r1", Amount: 300, State: TransactionStateSucceeded}}, OrderStatePartiallyRefunded, 700, nil},
+		{"fully refunded", []OrderOperation{{OrderId: "r1", Amount: 400, State: TransactionStateSucceeded}, {OrderId: "r2", Amount: 600, State: TransactionStateSucceeded}}, OrderStateRefunded, 0, nil},
+		{"pending refund", []OrderOperation{{OrderId: "r1", Amount: 300, State: TransactionStatePending}}, OrderStatePaid, 700, nil},
+		{"unknown refund is pending", []OrderOperation{{OrderId: "r1", Amount: 300}}, OrderStatePaid, 700, nil},
+		{"failed refund", []OrderOperation{{OrderId: "r1", Amount: 1000, State: TransactionStateFailed}}, OrderStatePaid, 1000, nil},
+		{"exceeded", []OrderOperation{{OrderId: "r1", Amount: 600, State: TransactionStateSucceeded}, {OrderId: "r2", Amount: 500}}, 0, 0, ErrRefundExceeded},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ledger = NewRefundLedger(nil)
+			order, err := ledger.Track("123456789012345678901", "20261019120000abcdef0001", "20261019120000", 1000, test.refunds...)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Track() error = %v, want %v", err, test.err)
+			}
+			if test.err != nil {
+				if ledger.Order("123456789012345678901") != nil {
+					t.Fatal("Track() registered the order on error")
+				}
+				return
+			}
+			if state := order.State(); state != test.state {
+				t.Fatalf("State() = %s, want %s", state, test.state)
+			}
+			if remaining, _ := ledger.Remaining("123456789012345678901"); remaining != test.remaining {
+				t.Fatalf("Remaining() = %d, want %d", remaining, test.remaining)
+			}
+			if refunds, _ := ledger.Refunds("123456789012345678901"); len(refunds) != len(test.refunds) {
+				t.Fatalf("Refunds() returned %d refunds, want %d", len(refunds), len(test.refunds))
+			}
+		})
+	}
+
+	t.Run("invalid refund", func(t *testing.T) {
+		var ledger = NewRefundLedger(nil)
+		if _, err := ledger.Track("1", "o1", "20261019120000", 1000, OrderOperation{OrderId: "r1", Amount: 0}); err == nil {
+			t.Fatal("Track() accepted a refund without amount")
+		}
+		if _, err := ledger.Track("1", "o1", "20261019120000", 1000, OrderOperation{TxnType: kTxnTypeRevoke, OrderId: "r1", Amount: 1000}); err == nil {
+			t.Fatal("Track() accepted a revoke as refund")
+		}
+	})
+}
+
+// 登记时带入的退货会占用可退货金额，处理中的退货可以通过 Reconcile 更新状态。
+func TestRefundLedger_SeededRefunds(t *testing.T) {
+	var pki = newTestPKI(t)
+	var requests int
+	var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		requests++
+		var rValues = testResponse(values, "00")
+		if api == kQueryTrans {
+			rValues.Set("txnType", kTxnTypeRefund)
+			rValues.Set("txnAmt", "300")
+			rValues.Set("queryId", "123456789012345678902")
+			rValues.Set("origRespCode", "00")
+		}
+		return rValues
+	})
+	var client = newTestClient(t, pki, WithGateway(server.URL), WithClock(ClockFunc(func() time.Time {
+		return time.Date(2026, 10, 20, 12, 0, 0, 0, kBeijing)
+	})))
+
+	var ledger = NewRefundLedger(client)
+	var queryId = "123456789012345678901"
+	if _, err := ledger.Track(queryId, "20261019120000abcdef0001", "20261019120000", 1000,
+		OrderOperation{OrderId: "20261019130000abcdef0002", TxnTime: "20261019130000", Amount: 600, State: TransactionStateSucceeded},
+		OrderOperation{OrderId: "20261019140000abcdef0003", TxnTime: "20261019140000", Amount: 300, State: TransactionStatePending},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ledger.Refund(context.Background(), queryId, "20261020120000abcdef0004", 200, "https://example.com/notify"); !errors.Is(err, ErrRefundExceeded) {
+		t.Fatalf("Refund() error = %v, want %v", err, ErrRefundExceeded)
+	}
+	if requests != 0 {
+		t.Fatalf("Refund() sent %d requests, want 0", requests)
+	}
+
+	if err := ledger.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var order = ledger.Order(queryId)
+	if state := order.State(); state != OrderStatePartiallyRefunded {
+		t.Fatalf("State() = %s, want %s", state, OrderStatePartiallyRefunded)
+	}
+	if refunded := order.Refunded(); refunded != 900 {
+		t.Fatalf("Refunded() = %d, want 900", refunded)
+	}
+
+	if _, err := ledger.Refund(context.Background(), queryId, "20261020120000abcdef0004", 100, "https://example.com/notify"); err != nil {
+		t.Fatal(err)
+	}
+	if remaining, _ := ledger.Remaining(queryId); remaining != 0 {
+		t.Fatalf("Remaining() = %d, want 0", remaining)
+	}
+}
+
+// orderId 为空时生成的退货订单号会记录到退货中，通知和查询都能匹配到这笔退货。
+func TestRefundLedger_RefundWithoutOrderId(t *testing.T) {
+	var pki = newTestPKI(t)
+	var now = time.Date(2026, 10, 19, 12, 0, 0, 0, kBeijing)
+
+	var sent url.Values
+	var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		if api == kBackTrans {
+			sent = values
+		}
+		var rValues = testResponse(values, "00")
+		rValues.Set("queryId", "123456789012345678902")
+		if api == kQueryTrans {
+			rValues.Set("txnType", kTxnTypeRefund)
+			rValues.Set("txnAmt", "300")
+			rValues.Set("origRespCode", "05")
+		}
+		return rValues
+	})
+	var client = newTestClient(t, pki, WithGateway(server.URL), WithClock(ClockFunc(func() time.Time { return now })))
+
+	var ledger = NewRefundLedger(client)
+	var queryId = "123456789012345678901"
+	if _, err := ledger.Track(queryId, "20261019100000abcdef0001", "20261019100000", 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	refund, err := ledger.Refund(context.Background(), queryId, "", 300, "https://example.com/notify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refunds, _ := ledger.Refunds(queryId)
+	if len(refunds) != 1 || refunds[0].OrderId == "" || refunds[0].OrderId != sent.Get("orderId") || refunds[0].OrderId != refund.OrderId {
+		t.Fatalf("Refunds() = %+v, want the orderId sent to the gateway %s", refunds, sent.Get("orderId"))
+	}
+
+	// 查询结果为处理中时退货依然占用可退货金额
+	if err = ledger.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if remaining, _ := ledger.Remaining(queryId); remaining != 700 {
+		t.Fatalf("Remaining() = %d, want 700", remaining)
+	}
+
+	var notification = &RefundNotification{}
+	notification.TxnType = kTxnTypeRefund
+	notification.OrderId = refunds[0].OrderId
+	notification.TxnTime = refunds[0].TxnTime
+	notification.TxnAmt = "300"
+	notification.OrgQryId = queryId
+	notification.QueryId = "123456789012345678902"
+	notification.Code = CodeSuccess
+	for i := 0; i < 2; i++ {
+		if err = ledger.ApplyNotification(notification); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if refunds, _ = ledger.Refunds(queryId); len(refunds) != 1 || refunds[0].State != TransactionStateSucceeded {
+		t.Fatalf("Refunds() = %+v, want one succeeded refund", refunds)
+	}
+	var order = ledger.Order(queryId)
+	if refunded, remaining := order.Refunded(), order.Refundable(); refunded != 300 || remaining != 700 {
+		t.Fatalf("Refunded() = %d, Refundable() = %d, want 300 and 700", refunded, remaining)
+	}
+	if state := order.State(); state != OrderStatePartiallyRefunded {
+		t.Fatalf("State() = %s, want %s", state, OrderStatePartiallyRefunded)
+	}
+}
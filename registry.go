@@ -0,0 +1,154 @@
+package unionpay
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownMerchant Registry 中没有对应商户号(merId)的 Client。
+var ErrUnknownMerchant = errors.New("unknown merchant")
+
+// Registry 管理多个商户的 Client，可以通过商户号(merId)获取 Client，并将银联通知分发到对应商户的 Client 进行验签和解析。
+//
+// 同一个 Registry 中的 Client 共享同一个 http.Client；相同环境（生产环境或者沙箱环境）的 Client 共享同一个验签缓存(VerifierCache)。
+type Registry struct {
+	mu      sync.RWMutex
+	clients map[string]*Client
+
+	httpClient *http.Client
+	sandbox    *VerifierCache
+	production *VerifierCache
+	opts       []OptionFunc
+}
+
+// NewRegistry 创建 Registry，opts 会作用于 Registry 中创建的所有 Client。
+func NewRegistry(opts ...OptionFunc) *Registry {
+	var nRegistry = &Registry{}
+	nRegistry.clients = make(map[string]*Client)
+	nRegistry.httpClient = &http.Client{}
+	nRegistry.sandbox = NewVerifierCache(kDefaultVerifierCacheSize)
+	nRegistry.production = NewVerifierCache(kDefaultVerifierCacheSize)
+	nRegistry.opts = opts
+	return nRegistry
+}
+
+// HTTPClient 返回各 Client 共享的 http.Client。
+func (r *Registry) HTTPClient() *http.Client {
+	return r.httpClient
+}
+
+// VerifierCache 返回指定环境的 Client 共享的验签缓存。
+func (r *Registry) VerifierCache(isProduction bool) *VerifierCache {
+	if isProduction {
+		return r.production
+	}
+	return r.sandbox
+}
+
+// Load 根据配置创建 Client 并添加到 Registry 中，会尝试加载所有配置并返回所有的错误信息。
+func (r *Registry) Load(configs ...*Config) error {
+	var errs []error
+	for _, config := range configs {
+		if _, err := r.Add(config); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Add 根据配置创建 Client 并添加到 Registry 中，已经存在相同商户号的 Client 时会被替换。
+//
+// 会先通过 Config.Validate 校验配置。OptionFunc 的执行顺序为：Registry 的配置、Config.Options()、opts；
+// 配置中设置了 Timeout 时，该 Client 会使用独立的 http.Client。
+func (r *Registry) Add(config *Config, opts ...OptionFunc) (*Client, error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("merchant %s: %w", config.MerchantId, err)
+	}
+
+	var cOpts = config.Options()
+	var nOpts = make([]OptionFunc, 0, len(r.opts)+len(cOpts)+len(opts)+2)
+	nOpts = append(nOpts, WithHTTPClient(r.httpClient), WithVerifierCache(r.VerifierCache(config.IsProduction())))
+	nOpts = append(nOpts, r.opts...)
+	nOpts = append(nOpts, cOpts...)
+	nOpts = append(nOpts, opts...)
+
+	client, err := config.newClient(nOpts)
+	if err != nil {
+		return nil, fmt.Errorf("merchant %s: %w", config.MerchantId, err)
+	}
+
+	r.Register(client)
+	return client, nil
+}
+
+// Register 添加已经创建好的 Client，已经存在相同商户号的 Client 时会被替换。
+//
+// 通过本方法添加的 Client 不会自动共享 http.Client 和验签缓存，可以在创建 Client 时使用 WithHTTPClient 和 WithVerifierCache。
+func (r *Registry) Register(client *Client) {
+	if client == nil {
+		return
+	}
+	r.mu.Lock()
+	r.clients[client.MerchantId()] = client
+	r.mu.Unlock()
+}
+
+// Remove 移除商户号对应的 Client。
+func (r *Registry) Remove(merchantId string) {
+	r.mu.Lock()
+	delete(r.clients, merchantId)
+	r.mu.Unlock()
+}
+
+// Client 根据商户号获取 Client，不存在时返回 ErrUnknownMerchant。
+func (r *Registry) Client(merchantId string) (*Client, error) {
+	r.mu.RLock()
+	var client = r.clients[merchantId]
+	r.mu.RUnlock()
+
+	if client == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMerchant, merchantId)
+	}
+	return client, nil
+}
+
+// MerchantIds 返回所有商户号，按字典序排列。
+func (r *Registry) MerchantIds() []string {
+	r.mu.RLock()
+	var ids = make([]string, 0, len(r.clients))
+	for id := range r.clients {
+		ids = append(ids, id)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(ids)
+	return ids
+}
+
+// VerifySign 根据通知中的商户号(merId)找到对应的 Client 进行验签。
+func (r *Registry) VerifySign(values url.Values) (*Client, error) {
+	client, err := r.Client(values.Get("merId"))
+	if err != nil {
+		return nil, err
+	}
+	return client, client.VerifySign(values)
+}
+
+// DecodeNotification 根据通知中的商户号(merId)找到对应的 Client 解析通知，参考 Client.DecodeNotification。
+//
+// 返回的 Client 可用于后续处理，如通过 GetTransaction 查询交易状态。
+func (r *Registry) DecodeNotification(values url.Values) (*Client, interface{}, error) {
+	client, err := r.Client(values.Get("merId"))
+	if err != nil {
+		return nil, nil, err
+	}
+	notification, err := client.DecodeNotification(values)
+	return client, notification, err
+}
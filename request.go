@@ -0,0 +1,245 @@
+package unionpay
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInvalidRequest 请求参数校验失败，参考 ValidationError。
+var ErrInvalidRequest = errors.New("invalid unionpay request")
+
+// ValidationError 请求参数校验失败，Problems 包含所有的问题。
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return ErrInvalidRequest.Error() + ": " + strings.Join(e.Problems, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+// EncodeRequest 将请求结构体中非空的字段按照 query 标签写入 values，已经存在的字段会被替换。
+//
+// 支持 string、整数类型以及实现了 encoding.TextMarshaler 的字段，匿名嵌入的结构体会被展开，标签为 - 的字段会被忽略。
+func EncodeRequest(values url.Values, src interface{}) error {
+	var v = reflect.Indirect(reflect.ValueOf(src))
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("unionpay: EncodeRequest requires a struct, got %T", src)
+	}
+	return encodeStruct(values, v)
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func encodeStruct(values url.Values, v reflect.Value) error {
+	var t = v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		var field = t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		var fv = v.Field(i)
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			if err := encodeStruct(values, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var name, _, _ = strings.Cut(field.Tag.Get("query"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		value, err := encodeField(fv)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	return nil
+}
+
+func encodeField(fv reflect.Value) (string, error) {
+	if fv.Type().Implements(textMarshalerType) {
+		if fv.Kind() == reflect.Pointer && fv.IsNil() {
+			return "", nil
+		}
+		text, err := fv.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fv.Int() == 0 {
+			return "", nil
+		}
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if fv.Uint() == 0 {
+			return "", nil
+		}
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported field type %s", fv.Type())
+}
+
+// validateRequest 使用 values 中的参数替换 req 副本中对应的字段之后进行校验，
+// 这样通过 WithPayload() 等 CallOption 设置的参数同样会经过校验，req 本身不会被修改。
+//
+// 支持 string 以及实现了 encoding.TextUnmarshaler 的字段（包括指针），解析失败时同样返回 ValidationError。
+func validateRequest(values url.Values, req interface{ Validate() error }) error {
+	var v = reflect.ValueOf(req)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return req.Validate()
+	}
+
+	var clone = reflect.New(v.Elem().Type())
+	clone.Elem().Set(v.Elem())
+	var decoded = &validator{}
+	decodeStruct(values, clone.Elem(), decoded)
+
+	var err = clone.Interface().(interface{ Validate() error }).Validate()
+	if len(decoded.problems) == 0 {
+		return err
+	}
+	var vErr *ValidationError
+	if errors.As(err, &vErr) {
+		decoded.problems = append(decoded.problems, vErr.Problems...)
+	}
+	return decoded.err()
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func decodeStruct(values url.Values, v reflect.Value, problems *validator) {
+	var t = v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		var field = t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		var fv = v.Field(i)
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			decodeStruct(values, fv, problems)
+			continue
+		}
+
+		var name, _, _ = strings.Cut(field.Tag.Get("query"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if _, ok := values[name]; !ok {
+			continue
+		}
+
+		switch {
+		case fv.Kind() == reflect.Pointer && fv.Type().Implements(textUnmarshalerType):
+			var pv = reflect.New(fv.Type().Elem())
+			if err := pv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values.Get(name))); err != nil {
+				problems.add("%s: %v", name, err)
+				continue
+			}
+			fv.Set(pv)
+		case fv.Addr().Type().Implements(textUnmarshalerType):
+			if err := fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values.Get(name))); err != nil {
+				problems.add("%s: %v", name, err)
+			}
+		case fv.Kind() == reflect.String:
+			fv.SetString(values.Get(name))
+		}
+	}
+}
+
+// validator 用于收集请求参数的校验问题。
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) required(name, value string) bool {
+	if value == "" {
+		v.add("%s is required", name)
+		return false
+	}
+	return true
+}
+
+func (v *validator) length(name, value string, min, max int) {
+	if value == "" {
+		return
+	}
+	if n := utf8.RuneCountInString(value); n < min || n > max {
+		if min == max {
+			v.add("%s must be %d characters, got %d", name, min, n)
+		} else {
+			v.add("%s must be %d-%d characters, got %d", name, min, max, n)
+		}
+	}
+}
+
+// amount 校验金额，单位为分，1-12 位数字且不能以 0 开头。
+func (v *validator) amount(name, value string) {
+	if value == "" {
+		return
+	}
+	if len(value) > 12 || value[0] == '0' || strings.Trim(value, "0123456789") != "" {
+		v.add("%s must be a positive integer amount in cents, got %q", name, value)
+	}
+}
+
+// timestamp 校验 YYYYMMDDhhmmss 格式的时间。
+func (v *validator) timestamp(name, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := time.Parse(kTimeFormat, value); err != nil || len(value) != len(kTimeFormat) {
+		v.add("%s must be in YYYYMMDDhhmmss format, got %q", name, value)
+	}
+}
+
+func (v *validator) url(name, value string) {
+	if value == "" {
+		return
+	}
+	if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add("%s must be an absolute http(s) URL, got %q", name, value)
+	}
+	v.length(name, value, 1, 256)
+}
+
+// orderId 校验商户订单号，参考 ValidateOrderId。
+func (v *validator) orderId(name, value string) {
+	if value == "" {
+		return
+	}
+	if err := ValidateOrderId(value); err != nil {
+		v.add("%s: %v", name, err)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
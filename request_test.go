@@ -0,0 +1,80 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// WithPayload 设置的参数同样需要经过校验。
+func TestClient_ValidatePayload(t *testing.T) {
+	var pki = newTestPKI(t)
+
+	var tests = []struct {
+		name   string
+		key    string
+		value  string
+		web    bool // CreateWebPaymentWith 是否校验失败
+		refund bool // Refund 是否校验失败，退货没有 orderDesc、payTimeout 等字段
+	}{
+		{"valid orderDesc", "orderDesc", "test order", false, false},
+		{"orderDesc too long", "orderDesc", strings.Repeat("a", 33), true, false},
+		{"invalid payTimeout", "payTimeout", "2026-10-19 12:00:00", true, false},
+		{"invalid txnTime", "txnTime", "20261019", true, true},
+		{"invalid accessType", "accessType", "9", true, true},
+		{"reqReserved too long", "reqReserved", strings.Repeat("a", 1025), true, true},
+		{"invalid currencyCode", "currencyCode", "1560", true, true},
+		{"valid reserved", "reserved", "{a=1&b=2}", false, false},
+		{"invalid reserved", "reserved", "a=1", true, true},
+		{"reserved too long", "reserved", "{a=" + strings.Repeat("a", 2046) + "}", true, true},
+		{"valid riskRateInfo", "riskRateInfo", "{sourceIP=10.0.0.1&scene=web}", false, false},
+		{"invalid riskRateInfo", "riskRateInfo", "sourceIP=10.0.0.1", true, false},
+		{"riskRateInfo too long", "riskRateInfo", "{userId=" + strings.Repeat("a", 2041) + "}", true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int
+			var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+				requests++
+				return testResponse(values, "00")
+			})
+			var client = newTestClient(t, pki, WithGateway(server.URL))
+			var payload = WithPayload(NewPayload().AddParam(test.key, test.value))
+
+			var req = &WebPaymentRequest{}
+			req.TxnAmt = "1000"
+			req.FrontURL = "https://example.com/return"
+			req.BackURL = "https://example.com/notify"
+			_, err := client.CreateWebPaymentWith(context.Background(), req, payload)
+			checkValidation(t, "CreateWebPaymentWith", err, test.web, test.key)
+			if req.OrderId == "" || req.ReqReserved != "" || req.TxnTime != "" || req.OrderDesc != "" {
+				t.Fatalf("request was modified by validation: %+v", req.ConsumeRequest)
+			}
+
+			_, err = client.Refund(context.Background(), "123456789012345678901", "20261019120000abcdef0002", "1000", "https://example.com/notify", payload)
+			checkValidation(t, "Refund", err, test.refund, test.key)
+			if test.refund && requests > 0 {
+				t.Fatal("invalid request was sent to the gateway")
+			}
+		})
+	}
+}
+
+func checkValidation(t *testing.T, name string, err error, invalid bool, key string) {
+	t.Helper()
+
+	if !invalid {
+		if err != nil {
+			t.Fatalf("%s() error = %v", name, err)
+		}
+		return
+	}
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || !strings.Contains(err.Error(), key) {
+		t.Fatalf("%s() error = %v, want ValidationError about %s", name, err, key)
+	}
+}
@@ -0,0 +1,208 @@
+package unionpay
+
+import (
+	"time"
+)
+
+// CommonRequest 各交易通用的可选字段，为空时使用默认值或者不上送。
+type CommonRequest struct {
+	TxnTime     string   `query:"txnTime"`     // 订单发送时间，格式为 YYYYMMDDhhmmss，为空时使用当前时间
+	AccessType  string   `query:"accessType"`  // 接入类型，为空时使用 Client 的接入类型
+	AcqInsCode  string   `query:"acqInsCode"`  // 收单机构代码
+	SubMerId    string   `query:"subMerId"`    // 二级商户代码
+	SubMerName  string   `query:"subMerName"`  // 二级商户全称
+	SubMerAbbr  string   `query:"subMerAbbr"`  // 二级商户简称
+	ReqReserved string   `query:"reqReserved"` // 请求方保留域，应答和通知中原样返回
+	Reserved    Reserved `query:"reserved"`    // 保留域
+}
+
+func (r *CommonRequest) validate(v *validator) {
+	v.timestamp("txnTime", r.TxnTime)
+	switch r.AccessType {
+	case "", AccessTypeMerchant, AccessTypeAcquirer, AccessTypePlatform:
+	default:
+		v.add("accessType must be 0, 1 or 2, got %q", r.AccessType)
+	}
+	v.length("acqInsCode", r.AcqInsCode, 1, 11)
+	v.length("subMerId", r.SubMerId, 1, 15)
+	v.length("subMerName", r.SubMerName, 1, 40)
+	v.length("subMerAbbr", r.SubMerAbbr, 1, 16)
+	v.length("reqReserved", r.ReqReserved, 1, 1024)
+	if reserved, err := r.Reserved.Encode(); err != nil {
+		v.add("reserved: %v", err)
+	} else {
+		v.length("reserved", reserved, 1, 2048)
+	}
+}
+
+// ConsumeRequest 消费类交易的公共字段。
+type ConsumeRequest struct {
+	CommonRequest
+	OrderId      string        `query:"orderId"`      // 商户消费订单号，8-32 位字母和数字，为空时自动生成
+	TxnAmt       string        `query:"txnAmt"`       // 交易金额，单位分，不要带小数点，必填
+	BackURL      string        `query:"backUrl"`      // 后台通知地址，必填
+	CurrencyCode string        `query:"currencyCode"` // 交易币种，默认为 156 - 人民币
+	ChannelType  string        `query:"channelType"`  // 渠道类型，07 - PC,平板  08 - 手机
+	BizType      string        `query:"bizType"`      // 产品类型
+	TxnSubType   string        `query:"txnSubType"`   // 交易子类，01：自助消费 03：分期付款
+	OrderDesc    string        `query:"orderDesc"`    // 订单描述
+	PayTimeout   time.Duration `query:"-"`            // 订单支付超时时长，相对于订单发送时间(txnTime)，为 0 时使用 WithDefaultPayTimeout 设置的时长
+	RiskRateInfo *RiskInfo     `query:"riskRateInfo"` // 风控信息域
+	AccSplitData AccSplitData  `query:"-"`            // 分账信息，各分账商户的金额之和需要等于 TxnAmt
+}
+
+func (r *ConsumeRequest) validate(v *validator) {
+	r.CommonRequest.validate(v)
+	if v.required("orderId", r.OrderId) {
+		v.orderId("orderId", r.OrderId)
+	}
+	if v.required("txnAmt", r.TxnAmt) {
+		v.amount("txnAmt", r.TxnAmt)
+	}
+	if v.required("backUrl", r.BackURL) {
+		v.url("backUrl", r.BackURL)
+	}
+	v.length("currencyCode", r.CurrencyCode, 3, 3)
+	v.length("channelType", r.ChannelType, 2, 2)
+	v.length("bizType", r.BizType, 6, 6)
+	v.length("txnSubType", r.TxnSubType, 2, 2)
+	v.length("orderDesc", r.OrderDesc, 1, 32)
+	if r.PayTimeout < 0 {
+		v.add("payTimeout must not be negative, got %s", r.PayTimeout)
+	}
+	if len(r.AccSplitData) > 0 && r.TxnAmt != "" {
+		if err := r.AccSplitData.Validate(r.TxnAmt); err != nil {
+			v.add("accSplitData: %v", err)
+		}
+	}
+	if info, err := r.RiskRateInfo.Encode(); err != nil {
+		v.add("riskRateInfo: %v", err)
+	} else {
+		v.length("riskRateInfo", info, 1, 2048)
+	}
+}
+
+// WebPaymentRequest 消费接口-创建网页支付的请求参数，参考 CreateWebPayment。
+type WebPaymentRequest struct {
+	ConsumeRequest
+	FrontURL string `query:"frontUrl"` // 前台通知地址，必填
+}
+
+// Validate 校验请求参数。
+func (r *WebPaymentRequest) Validate() error {
+	var v = &validator{}
+	r.ConsumeRequest.validate(v)
+	if v.required("frontUrl", r.FrontURL) {
+		v.url("frontUrl", r.FrontURL)
+	}
+	return v.err()
+}
+
+// AppPaymentRequest 消费接口-创建 App 支付的请求参数，参考 CreateAppPayment。
+type AppPaymentRequest struct {
+	ConsumeRequest
+}
+
+// Validate 校验请求参数。
+func (r *AppPaymentRequest) Validate() error {
+	var v = &validator{}
+	r.ConsumeRequest.validate(v)
+	return v.err()
+}
+
+// AccountPaymentRequest 无跳转支付-消费接口的请求参数，参考 CreateAccountPayment。
+//
+// AccNo 和 Customer 会使用敏感信息加密证书加密之后上送。
+type AccountPaymentRequest struct {
+	ConsumeRequest
+	AccType  string    `query:"accType"` // 账号类型，默认为 01：银行卡
+	AccNo    string    `query:"-"`       // 账号、卡号，必填
+	Customer *Customer `query:"-"`       // 银行卡验证信息及身份信息
+}
+
+// Validate 校验请求参数。
+func (r *AccountPaymentRequest) Validate() error {
+	var v = &validator{}
+	r.ConsumeRequest.validate(v)
+	v.length("accType", r.AccType, 2, 2)
+	if v.required("accNo", r.AccNo) {
+		v.length("accNo", r.AccNo, 1, 19)
+	}
+	return v.err()
+}
+
+// QueryRequest 交易状态查询接口的请求参数，参考 GetTransaction。
+type QueryRequest struct {
+	OrderId     string   `query:"orderId"`     // 被查询交易的商户订单号，必填
+	TxnTime     string   `query:"txnTime"`     // 被查询交易的订单发送时间，必填
+	AccessType  string   `query:"accessType"`  // 接入类型，为空时使用 Client 的接入类型
+	AcqInsCode  string   `query:"acqInsCode"`  // 收单机构代码
+	SubMerId    string   `query:"subMerId"`    // 二级商户代码
+	SubMerName  string   `query:"subMerName"`  // 二级商户全称
+	SubMerAbbr  string   `query:"subMerAbbr"`  // 二级商户简称
+	ReqReserved string   `query:"reqReserved"` // 请求方保留域
+	Reserved    Reserved `query:"reserved"`    // 保留域
+}
+
+// Validate 校验请求参数。
+func (r *QueryRequest) Validate() error {
+	var v = &validator{}
+	if v.required("orderId", r.OrderId) {
+		v.orderId("orderId", r.OrderId)
+	}
+	if v.required("txnTime", r.TxnTime) {
+		v.timestamp("txnTime", r.TxnTime)
+	}
+	var common = CommonRequest{AccessType: r.AccessType, AcqInsCode: r.AcqInsCode, SubMerId: r.SubMerId, SubMerName: r.SubMerName, SubMerAbbr: r.SubMerAbbr, ReqReserved: r.ReqReserved, Reserved: r.Reserved}
+	common.validate(v)
+	return v.err()
+}
+
+// ReverseRequest 无跳转支付-冲正的请求参数，参考 ReverseAccountPayment。
+type ReverseRequest struct {
+	QueryRequest
+}
+
+// CancelRequest 消费撤销和退货的公共字段。
+type CancelRequest struct {
+	CommonRequest
+	OrigQryId    string `query:"origQryId"`    // 原消费交易返回的的 queryId，必填
+	OrderId      string `query:"orderId"`      // 商户消费撤销或者退货订单号，8-32 位字母和数字，为空时自动生成
+	TxnAmt       string `query:"txnAmt"`       // 交易金额，单位分，不要带小数点，必填
+	BackURL      string `query:"backUrl"`      // 后台通知地址，必填
+	CurrencyCode string `query:"currencyCode"` // 交易币种，默认为 156 - 人民币
+	ChannelType  string `query:"channelType"`  // 渠道类型，07 - PC,平板  08 - 手机
+	BizType      string `query:"bizType"`      // 产品类型
+}
+
+// Validate 校验请求参数。
+func (r *CancelRequest) Validate() error {
+	var v = &validator{}
+	r.CommonRequest.validate(v)
+	if v.required("origQryId", r.OrigQryId) {
+		v.length("origQryId", r.OrigQryId, 21, 21)
+	}
+	if v.required("orderId", r.OrderId) {
+		v.orderId("orderId", r.OrderId)
+	}
+	if v.required("txnAmt", r.TxnAmt) {
+		v.amount("txnAmt", r.TxnAmt)
+	}
+	if v.required("backUrl", r.BackURL) {
+		v.url("backUrl", r.BackURL)
+	}
+	v.length("currencyCode", r.CurrencyCode, 3, 3)
+	v.length("channelType", r.ChannelType, 2, 2)
+	v.length("bizType", r.BizType, 6, 6)
+	return v.err()
+}
+
+// RevokeRequest 消费撤销接口的请求参数，参考 Revoke。
+type RevokeRequest struct {
+	CancelRequest
+}
+
+// RefundRequest 退货接口的请求参数，参考 Refund。
+type RefundRequest struct {
+	CancelRequest
+}
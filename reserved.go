@@ -0,0 +1,141 @@
+package unionpay
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/smartwalle/unionpay/internal"
+	"net/url"
+	"strings"
+)
+
+const kReqReservedMaxLength = 1024
+
+// ErrReservedValue reserved 中的键或者值包含 &、=、{、} 等银联报文中的保留字符。
+var ErrReservedValue = errors.New("invalid reserved value")
+
+// EncodeReqReserved 将 v 编码为 JSON，再使用 base64.RawURLEncoding 编码，用于请求方保留域(reqReserved)。
+//
+// 编码之后只包含字母、数字、- 和 _，不会影响银联报文的解析；reqReserved 会在应答和通知中原样返回，可以通过 DecodeReqReserved 解码。
+//
+// 编码之后的长度不能超过 1024。
+func EncodeReqReserved(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var s = base64.RawURLEncoding.EncodeToString(data)
+	if len(s) > kReqReservedMaxLength {
+		return "", fmt.Errorf("reqReserved is %d characters after encoding, exceeds %d", len(s), kReqReservedMaxLength)
+	}
+	return s, nil
+}
+
+// DecodeReqReserved 解码通过 EncodeReqReserved 编码的请求方保留域(reqReserved)。
+func DecodeReqReserved(s string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// DecodeReqReserved 解码请求方保留域(reqReserved)，参考 EncodeReqReserved。
+func (p *AppPayment) DecodeReqReserved(v interface{}) error {
+	return DecodeReqReserved(p.ReqReserved, v)
+}
+
+// DecodeReqReserved 解码请求方保留域(reqReserved)，参考 EncodeReqReserved。
+func (t *Transaction) DecodeReqReserved(v interface{}) error {
+	return DecodeReqReserved(t.ReqReserved, v)
+}
+
+// DecodeReqReserved 解码请求方保留域(reqReserved)，参考 EncodeReqReserved。
+func (r *Revoke) DecodeReqReserved(v interface{}) error {
+	return DecodeReqReserved(r.ReqReserved, v)
+}
+
+// DecodeReqReserved 解码请求方保留域(reqReserved)，参考 EncodeReqReserved。
+//
+// RevokeNotification 和 RefundNotification 同样适用。
+func (r *Refund) DecodeReqReserved(v interface{}) error {
+	return DecodeReqReserved(r.ReqReserved, v)
+}
+
+// DecodeReqReserved 解码请求方保留域(reqReserved)，参考 EncodeReqReserved。
+func (p *AccountPayment) DecodeReqReserved(v interface{}) error {
+	return DecodeReqReserved(p.ReqReserved, v)
+}
+
+// DecodeReqReserved 解码请求方保留域(reqReserved)，参考 EncodeReqReserved。
+func (r *Reverse) DecodeReqReserved(v interface{}) error {
+	return DecodeReqReserved(r.ReqReserved, v)
+}
+
+// DecodeReqReserved 解码请求方保留域(reqReserved)，参考 EncodeReqReserved。
+func (n *PaymentNotification) DecodeReqReserved(v interface{}) error {
+	return DecodeReqReserved(n.ReqReserved, v)
+}
+
+// Reserved 保留域(reserved)，银联使用 {key=value&key=value} 的格式。
+//
+//	var req = &unionpay.RefundRequest{}
+//	req.Reserved = unionpay.Reserved{}
+//	req.Reserved.Set("cardNumberLock", "1") // reserved={cardNumberLock=1}
+//
+// 通过请求结构体中的 Reserved 字段设置，编码失败时请求会返回错误，不会发送给银联。
+type Reserved map[string]string
+
+// Set 设置保留域中的字段。
+func (r Reserved) Set(key, value string) {
+	r[key] = value
+}
+
+// Get 获取保留域中的字段。
+func (r Reserved) Get(key string) string {
+	return r[key]
+}
+
+// Encode 将保留域编码为 {key=value&key=value} 格式，键按照字典序排列；没有字段时返回空字符串。
+//
+// 键和值中不能包含 &、=、{、}，否则返回 ErrReservedValue。
+func (r Reserved) Encode() (string, error) {
+	var values = url.Values{}
+	for key, value := range r {
+		if strings.ContainsAny(key, "&={}") || strings.ContainsAny(value, "&={}") {
+			return "", fmt.Errorf("%w: %s=%s", ErrReservedValue, key, value)
+		}
+		values.Set(key, value)
+	}
+	return internal.EncodeBraced(values), nil
+}
+
+// MarshalText 实现 encoding.TextMarshaler，用于请求结构体中的 Reserved 字段。
+func (r Reserved) MarshalText() ([]byte, error) {
+	s, err := r.Encode()
+	return []byte(s), err
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，参考 ParseReserved。
+func (r *Reserved) UnmarshalText(text []byte) error {
+	reserved, err := ParseReserved(string(text))
+	if err != nil {
+		return err
+	}
+	*r = reserved
+	return nil
+}
+
+// ParseReserved 解析 {key=value&key=value} 格式的保留域(reserved)。
+func ParseReserved(s string) (Reserved, error) {
+	values, err := internal.ParseBraced(s)
+	if err != nil {
+		return nil, err
+	}
+	var reserved = make(Reserved, len(values))
+	for key := range values {
+		reserved[key] = values.Get(key)
+	}
+	return reserved, nil
+}
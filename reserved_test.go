@@ -0,0 +1,134 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestReserved_Encode(t *testing.T) {
+	var tests = []struct {
+		name     string
+		reserved Reserved
+		want     string
+		err      error
+	}{
+		{"empty", nil, "", nil},
+		{"single", Reserved{"cardNumberLock": "1"}, "{cardNumberLock=1}", nil},
+		{"sorted", Reserved{"b": "2", "a": "1"}, "{a=1&b=2}", nil},
+		{"empty value", Reserved{"a": ""}, "{a=}", nil},
+		{"& in value", Reserved{"a": "1&b=2"}, "", ErrReservedValue},
+		{"brace in key", Reserved{"{a": "1"}, "", ErrReservedValue},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.reserved.Encode()
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Encode() error = %v, want %v", err, test.err)
+			}
+			if got != test.want {
+				t.Fatalf("Encode() = %q, want %q", got, test.want)
+			}
+			if err != nil || got == "" {
+				return
+			}
+
+			parsed, err := ParseReserved(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(parsed, test.reserved) {
+				t.Fatalf("ParseReserved() = %v, want %v", parsed, test.reserved)
+			}
+		})
+	}
+
+	for _, s := range []string{"a=1", "{a=1", "a=1}"} {
+		if _, err := ParseReserved(s); err == nil {
+			t.Errorf("ParseReserved(%q) accepted an invalid value", s)
+		}
+	}
+}
+
+// 应答中 {} 包裹的 reserved 以及包含单独的 { 或者 } 的 respMsg 都能正确解析，并且通过签名验证。
+func TestClient_ReservedResponse(t *testing.T) {
+	var pki = newTestPKI(t)
+
+	var tests = []struct {
+		name     string
+		reserved Reserved
+		respMsg  string
+	}{
+		{"reserved with &", Reserved{"a": "1", "b": "2"}, "成功"},
+		{"unbalanced { in respMsg", Reserved{"a": "1", "b": "2"}, "失败{原因"},
+		{"leading { in respMsg", nil, "{失败"},
+		{"text after } in respMsg", Reserved{"a": "1"}, "{失败}原因"},
+		{"unbalanced } in respMsg", Reserved{"a": "1"}, "失败}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+				var rValues = testResponse(values, "00")
+				rValues.Set("respMsg", test.respMsg)
+				rValues.Set("queryId", "123456789012345678902")
+				return rValues
+			})
+			var client = newTestClient(t, pki, WithGateway(server.URL))
+
+			var req = &RefundRequest{}
+			req.OrigQryId = "123456789012345678901"
+			req.TxnAmt = "1000"
+			req.BackURL = "https://example.com/notify"
+			req.Reserved = test.reserved
+			refund, err := client.RefundWith(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want, _ = test.reserved.Encode()
+			if refund.Reserved != want {
+				t.Fatalf("Reserved = %q, want %q", refund.Reserved, want)
+			}
+			if refund.Msg != test.respMsg || refund.QueryId != "123456789012345678902" || refund.TxnAmt != "1000" {
+				t.Fatalf("unexpected refund %+v", refund)
+			}
+			if len(test.reserved) == 0 {
+				return
+			}
+			reserved, err := ParseReserved(refund.Reserved)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(reserved, test.reserved) {
+				t.Fatalf("ParseReserved() = %v, want %v", reserved, test.reserved)
+			}
+		})
+	}
+}
+
+// 无法编码的 Reserved 会返回错误，不会发送给银联。
+func TestClient_InvalidReserved(t *testing.T) {
+	var pki = newTestPKI(t)
+	var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		t.Error("invalid request was sent to the gateway")
+		return testResponse(values, "00")
+	})
+	var client = newTestClient(t, pki, WithGateway(server.URL))
+
+	var req = &RefundRequest{}
+	req.OrigQryId = "123456789012345678901"
+	req.TxnAmt = "1000"
+	req.BackURL = "https://example.com/notify"
+	req.Reserved = Reserved{"a": "1&b=2"}
+	if _, err := client.RefundWith(context.Background(), req); !errors.Is(err, ErrReservedValue) {
+		t.Fatalf("RefundWith() error = %v, want ErrReservedValue", err)
+	}
+	if err := req.Validate(); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("Validate() error = %v, want ValidationError", err)
+	}
+}
@@ -0,0 +1,191 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/smartwalle/ngx"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RetryPolicy 重试策略，在网络错误或者银联返回 5xx 时生效。
+//
+// 查询类交易（交易状态查询、加密公钥更新查询）会直接重新发起请求。
+//
+// 其它交易（消费、消费撤销、退货等）无法确定银联是否已经收到请求，直接重新发起可能导致重复交易，所以会先使用相同的 orderId 和 txnTime 查询交易状态：
+//
+// 查无此交易时，使用相同的 orderId 和 txnTime 重新发起请求；
+//
+// 查询到交易时，不会重新发起请求，而是将查询结果中的原交易应答码(origRespCode)作为本次请求的应答码返回；
+//
+// 无法确定交易状态时，返回 AmbiguousOutcomeError。
+type RetryPolicy struct {
+	MaxAttempts int           // 最大请求次数（包括首次请求），小于等于 1 时不重试
+	Backoff     time.Duration // 首次重试的间隔，之后每次翻倍，默认为 500 毫秒
+	MaxBackoff  time.Duration // 最大重试间隔，默认为 10 秒
+}
+
+// WithRetryPolicy 设置重试策略。
+func WithRetryPolicy(policy RetryPolicy) OptionFunc {
+	return func(c *Client) {
+		if policy.MaxAttempts <= 1 {
+			c.retryPolicy = nil
+			return
+		}
+		if policy.Backoff <= 0 {
+			policy.Backoff = 500 * time.Millisecond
+		}
+		if policy.MaxBackoff <= 0 {
+			policy.MaxBackoff = 10 * time.Second
+		}
+		c.retryPolicy = &policy
+	}
+}
+
+// HTTPError 银联返回的 HTTP 状态码不是 200。
+type HTTPError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected http status %d", e.StatusCode)
+}
+
+// ErrAmbiguousOutcome 无法确定交易是否已经被银联受理，参考 AmbiguousOutcomeError。
+var ErrAmbiguousOutcome = errors.New("ambiguous transaction outcome")
+
+// AmbiguousOutcomeError 交易请求已经提交给银联，但是发生网络错误、银联返回 5xx 或者 context 被取消（超时），并且没有设置重试策略或者重试期间无法通过交易状态查询确定交易结果。
+//
+// 此时交易可能已经被银联受理，不能使用新的 orderId 重新发起交易，需要稍后使用 OrderId 和 TxnTime 查询交易状态。
+type AmbiguousOutcomeError struct {
+	OrderId string
+	TxnTime string
+	Err     error // 最后一次请求的错误信息
+}
+
+func (e *AmbiguousOutcomeError) Error() string {
+	return fmt.Sprintf("ambiguous outcome of order %s (txnTime %s): %v", e.OrderId, e.TxnTime, e.Err)
+}
+
+func (e *AmbiguousOutcomeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *AmbiguousOutcomeError) Is(target error) bool {
+	return target == ErrAmbiguousOutcome
+}
+
+// isTransient 判断请求提交之后是否无法确定银联的处理结果：网络错误、5xx 以及 context 被取消或者超时。
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var hErr *HTTPError
+	if errors.As(err, &hErr) {
+		return hErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var nErr net.Error
+	if errors.As(err, &nErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isIdempotent 判断交易是否可以直接重新发起。
+func isIdempotent(values url.Values) bool {
+	switch values.Get("txnType") {
+	case "00", "95": // 交易状态查询、加密公钥更新查询
+		return true
+	}
+	return false
+}
+
+func (c *Client) retry(ctx context.Context, api string, values url.Values, err error) (url.Values, error) {
+	var policy = c.retryPolicy
+	var backoff = policy.Backoff
+	var idempotent = isIdempotent(values)
+
+	for attempt := 1; attempt < policy.MaxAttempts; attempt++ {
+		var timer = time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, c.ambiguous(values, idempotent, ctx.Err())
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+
+		if idempotent {
+			rValues, _, rErr := c.request(ctx, api, values)
+			if rErr == nil || !isTransient(rErr) {
+				return rValues, rErr
+			}
+			err = rErr
+			continue
+		}
+
+		qValues, transaction, qErr := c.resolve(ctx, values)
+		switch ResolveTransaction(transaction, qErr) {
+		case TransactionStateNotFound:
+			// 银联没有收到该交易，使用相同的 orderId 和 txnTime 重新发起
+			rValues, _, rErr := c.request(ctx, api, values)
+			if rErr == nil || !isTransient(rErr) {
+				return rValues, rErr
+			}
+			err = rErr
+		case TransactionStateSucceeded, TransactionStateFailed, TransactionStatePending, TransactionStatePaidAfterTimeout:
+			if api != kBackTrans {
+				// 查询结果中没有 tn 等字段，无法替代原交易的应答
+				return nil, c.ambiguous(values, idempotent, err)
+			}
+			var rValues = ngx.CloneValues(qValues)
+			rValues.Set("respCode", transaction.OrigRespCode)
+			rValues.Set("respMsg", transaction.OrigRespMsg)
+			return rValues, nil
+		}
+	}
+	return nil, c.ambiguous(values, idempotent, err)
+}
+
+// resolve 使用交易的 orderId 和 txnTime 查询交易状态，不会触发重试。
+func (c *Client) resolve(ctx context.Context, values url.Values) (url.Values, *Transaction, error) {
+	var qValues = url.Values{}
+	qValues.Set("accessType", values.Get("accessType"))
+	for _, key := range []string{"acqInsCode", "subMerId", "subMerName", "subMerAbbr"} {
+		if value := values.Get(key); value != "" {
+			qValues.Set(key, value)
+		}
+	}
+	qValues.Set("bizType", "000000")
+	qValues.Set("txnType", "00")
+	qValues.Set("txnSubType", "00")
+	qValues.Set("orderId", values.Get("orderId"))
+	qValues.Set("txnTime", values.Get("txnTime"))
+
+	rValues, _, err := c.request(ctx, kQueryTrans, qValues)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var transaction *Transaction
+	if err = DecodeValues(rValues, &transaction); err != nil {
+		return nil, nil, err
+	}
+	return rValues, transaction, transaction.err()
+}
+
+func (c *Client) ambiguous(values url.Values, idempotent bool, err error) error {
+	if idempotent {
+		return err
+	}
+	return &AmbiguousOutcomeError{OrderId: values.Get("orderId"), TxnTime: values.Get("txnTime"), Err: err}
+}
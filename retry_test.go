@@ -0,0 +1,180 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testRetryStep 测试网关的一次应答：status 不为 0 时返回该 HTTP 状态码，hang 为 true 时不返回应答，否则返回应答码 code。
+type testRetryStep struct {
+	status int
+	hang   bool
+	code   string
+}
+
+func TestClient_RequestRetry(t *testing.T) {
+	var pki = newTestPKI(t)
+	var policy = RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	var (
+		ok       = testRetryStep{code: "00"}
+		declined = testRetryStep{code: "30"}
+		failure  = testRetryStep{status: http.StatusInternalServerError}
+		bad      = testRetryStep{status: http.StatusBadRequest}
+		hang     = testRetryStep{hang: true}
+		notFound = testRetryStep{code: "34"}
+	)
+
+	var tests = []struct {
+		name    string
+		opts    []OptionFunc
+		timeout time.Duration
+		back    []testRetryStep // 退货接口依次返回的结果
+		query   []testRetryStep // 交易状态查询接口依次返回的结果，code 为原交易应答码，34 表示查无此交易
+		code    Code
+		err     error
+		sent    int // 退货接口收到的请求次数
+	}{
+		{"success", nil, 0, []testRetryStep{ok}, nil, CodeSuccess, nil, 1},
+		{"declined is final", []OptionFunc{WithRetryPolicy(policy)}, 0, []testRetryStep{declined}, nil, CodeDeclined, nil, 1},
+		{"4xx is not ambiguous", nil, 0, []testRetryStep{bad}, nil, "", &HTTPError{}, 1},
+		{"5xx without retry", nil, 0, []testRetryStep{failure}, nil, "", ErrAmbiguousOutcome, 1},
+		{"timeout without retry", nil, 20 * time.Millisecond, []testRetryStep{hang}, nil, "", ErrAmbiguousOutcome, 1},
+		{"timeout with retry", []OptionFunc{WithRetryPolicy(policy)}, 20 * time.Millisecond, []testRetryStep{hang}, nil, "", ErrAmbiguousOutcome, 1},
+		{"resend when not found", []OptionFunc{WithRetryPolicy(policy)}, 0, []testRetryStep{failure, ok}, []testRetryStep{notFound}, CodeSuccess, nil, 2},
+		{"resolved by query", []OptionFunc{WithRetryPolicy(policy)}, 0, []testRetryStep{failure}, []testRetryStep{ok}, CodeSuccess, nil, 1},
+		{"query shows failure", []OptionFunc{WithRetryPolicy(policy)}, 0, []testRetryStep{failure}, []testRetryStep{declined}, CodeDeclined, nil, 1},
+		{"query keeps failing", []OptionFunc{WithRetryPolicy(policy)}, 0, []testRetryStep{failure}, []testRetryStep{failure, failure}, "", ErrAmbiguousOutcome, 1},
+		{"resend keeps failing", []OptionFunc{WithRetryPolicy(policy)}, 0, []testRetryStep{failure, failure, failure}, []testRetryStep{notFound, notFound}, "", ErrAmbiguousOutcome, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var back, query int
+			var next = func(steps []testRetryStep, n *int) testRetryStep {
+				mu.Lock()
+				defer mu.Unlock()
+				*n++
+				if *n > len(steps) {
+					t.Errorf("unexpected request #%d", *n)
+					return failure
+				}
+				return steps[*n-1]
+			}
+
+			var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+				var step testRetryStep
+				if api == kQueryTrans {
+					step = next(test.query, &query)
+				} else {
+					step = next(test.back, &back)
+				}
+				switch {
+				case step.hang:
+					time.Sleep(10 * test.timeout)
+					return nil
+				case step.status != 0:
+					w.WriteHeader(step.status)
+					return nil
+				}
+
+				if api != kQueryTrans {
+					return testResponse(values, step.code)
+				}
+				if step.code == "34" {
+					return testResponse(values, "34")
+				}
+				var rValues = testResponse(values, "00")
+				rValues.Set("origRespCode", step.code)
+				rValues.Set("origRespMsg", "test "+step.code)
+				rValues.Set("queryId", "123456789012345678902")
+				return rValues
+			})
+			var client = newTestClient(t, pki, append(test.opts, WithGateway(server.URL))...)
+
+			var ctx = context.Background()
+			if test.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				defer cancel()
+			}
+
+			refund, err := client.Refund(ctx, "123456789012345678901", "20261019120000abcdef0002", "1000", "https://example.com/notify")
+			switch target := test.err.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("Refund() error = %v", err)
+				}
+				if refund.Code != test.code {
+					t.Fatalf("Refund() respCode = %s, want %s", refund.Code, test.code)
+				}
+			case *HTTPError:
+				if !errors.As(err, &target) || errors.Is(err, ErrAmbiguousOutcome) {
+					t.Fatalf("Refund() error = %v, want a plain HTTPError", err)
+				}
+			default:
+				if !errors.Is(err, test.err) {
+					t.Fatalf("Refund() error = %v, want %v", err, test.err)
+				}
+			}
+
+			var aErr *AmbiguousOutcomeError
+			if errors.As(err, &aErr) && (aErr.OrderId != "20261019120000abcdef0002" || aErr.TxnTime == "") {
+				t.Fatalf("AmbiguousOutcomeError = %+v, want orderId and txnTime of the refund", aErr)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if back != test.sent {
+				t.Fatalf("refund requests = %d, want %d", back, test.sent)
+			}
+			if query != len(test.query) {
+				t.Fatalf("query requests = %d, want %d", query, len(test.query))
+			}
+		})
+	}
+}
+
+// 查询类交易可以直接重试，失败时返回原始错误。
+func TestClient_RequestRetryIdempotent(t *testing.T) {
+	var pki = newTestPKI(t)
+	var requests int
+	var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+		return nil
+	})
+	var client = newTestClient(t, pki, WithGateway(server.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}))
+
+	_, err := client.GetTransaction(context.Background(), "20261019120000abcdef0001", "20261019120000")
+	var hErr *HTTPError
+	if !errors.As(err, &hErr) || errors.Is(err, ErrAmbiguousOutcome) {
+		t.Fatalf("GetTransaction() error = %v, want a plain HTTPError", err)
+	}
+	if requests != 3 {
+		t.Fatalf("requests = %d, want 3", requests)
+	}
+}
+
+// context 在提交请求之前已经结束时，请求不会提交给银联，返回 context 的错误。
+func TestClient_RequestCanceledBeforeSend(t *testing.T) {
+	var pki = newTestPKI(t)
+	var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		t.Errorf("unexpected request to %s", api)
+		return nil
+	})
+	var client = newTestClient(t, pki, WithGateway(server.URL))
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err := client.Refund(ctx, "123456789012345678901", "20261019120000abcdef0002", "1000", "https://example.com/notify")
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrAmbiguousOutcome) {
+		t.Fatalf("Refund() error = %v, want %v", err, context.Canceled)
+	}
+}
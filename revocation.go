@@ -0,0 +1,119 @@
+package unionpay
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/smartwalle/unionpay/internal"
+	"os"
+)
+
+// ErrCertificateRevoked 银联签名公钥证书(signPubKeyCert)已被吊销。
+var ErrCertificateRevoked = errors.New("certificate has been revoked")
+
+// ErrCRLExpired 证书吊销列表(CRL)已经过了下一次更新时间(NextUpdate)，需要获取新的吊销列表。
+var ErrCRLExpired = internal.ErrCRLExpired
+
+// revokedKey 吊销列表中的证书由签发者和序列号共同确定，不同签发者签发的证书序列号可能相同。
+type revokedKey struct {
+	issuer string
+	serial string
+}
+
+func newRevokedKey(certificate *x509.Certificate) revokedKey {
+	return revokedKey{issuer: string(certificate.RawIssuer), serial: certificate.SerialNumber.String()}
+}
+
+// CRLFetcher 用于获取证书吊销列表(CRL)，返回 PEM 或者 DER 格式的数据。
+type CRLFetcher interface {
+	FetchCRL(ctx context.Context) ([]byte, error)
+}
+
+type CRLFetcherFunc func(ctx context.Context) ([]byte, error)
+
+func (f CRLFetcherFunc) FetchCRL(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// WithCRLFetcher 设置证书吊销列表(CRL)的获取方式，配合 RefreshCRL 使用。
+func WithCRLFetcher(fetcher CRLFetcher) OptionFunc {
+	return func(c *Client) {
+		c.crlFetcher = fetcher
+	}
+}
+
+// LoadCRL 加载证书吊销列表(CRL)，支持 PEM 和 DER 格式。
+//
+// 如果已经加载了银联根证书或者中间证书，会使用其验证吊销列表的签名；吊销列表的下一次更新时间(NextUpdate)已经过去时返回 ErrCRLExpired，并继续使用之前加载的吊销列表。
+//
+// 加载成功之后，每次验签（包括命中验签缓存时）都会检查银联签名公钥证书(signPubKeyCert)是否已被吊销，已被吊销的证书会从缓存中移除，并返回 ErrCertificateRevoked。
+//
+// 每次加载都会替换之前加载的吊销列表。
+func (c *Client) LoadCRL(b []byte) error {
+	var issuers []*x509.Certificate
+	if c.interCert != nil {
+		issuers = append(issuers, c.interCert)
+	}
+	if c.rootCert != nil {
+		issuers = append(issuers, c.rootCert)
+	}
+
+	crl, err := internal.ParseCRL(b, c.now(), issuers...)
+	if err != nil {
+		return err
+	}
+
+	var revoked = make(map[revokedKey]struct{}, len(crl.RevokedCertificateEntries))
+	for _, entry := range crl.RevokedCertificateEntries {
+		revoked[revokedKey{issuer: string(crl.RawIssuer), serial: entry.SerialNumber.String()}] = struct{}{}
+	}
+
+	c.mu.Lock()
+	c.revoked = revoked
+	c.mu.Unlock()
+
+	c.verifiers.removeFunc(func(cert *x509.Certificate) bool {
+		_, ok := revoked[newRevokedKey(cert)]
+		return ok
+	})
+	return nil
+}
+
+// LoadCRLFromFile 从文件加载证书吊销列表(CRL)。
+func (c *Client) LoadCRLFromFile(filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return c.LoadCRL(b)
+}
+
+// RefreshCRL 通过 WithCRLFetcher 设置的 CRLFetcher 获取并加载最新的证书吊销列表(CRL)。
+func (c *Client) RefreshCRL(ctx context.Context) error {
+	if c.crlFetcher == nil {
+		return errors.New("crl fetcher not found, you need to use WithCRLFetcher() first")
+	}
+
+	b, err := c.crlFetcher.FetchCRL(ctx)
+	if err != nil {
+		return err
+	}
+	return c.LoadCRL(b)
+}
+
+// checkRevoked 检查证书是否已被吊销。
+func (c *Client) checkRevoked(certificate *x509.Certificate) error {
+	c.mu.RLock()
+	var revoked = c.revoked
+	c.mu.RUnlock()
+
+	if revoked == nil {
+		return nil
+	}
+	var key = newRevokedKey(certificate)
+	if _, ok := revoked[key]; ok {
+		return fmt.Errorf("%w: %s", ErrCertificateRevoked, key.serial)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func testSignedValues(t *testing.T, pki *testPKI, certPEM string) url.Values {
+	var values = url.Values{}
+	values.Set("merId", kTestMerchantId)
+	values.Set("orderId", "20261019120000abcdef0001")
+	values.Set("respCode", "00")
+	return pki.sign(t, values, certPEM)
+}
+
+func TestClient_RefreshCRL(t *testing.T) {
+	var pki = newTestPKI(t)
+	var other = newTestPKI(t)
+	var errFetch = errors.New("fetch failed")
+
+	var tests = []struct {
+		name       string
+		fetch      func() ([]byte, error)
+		refreshErr error
+		verifyErr  error
+	}{
+		{"empty crl", func() ([]byte, error) { return pki.crl(t), nil }, nil, nil},
+		{"revoked", func() ([]byte, error) { return pki.crl(t, pki.cert), nil }, nil, ErrCertificateRevoked},
+		{"fetch error", func() ([]byte, error) { return nil, errFetch }, errFetch, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var client = newTestClient(t, pki, WithCRLFetcher(CRLFetcherFunc(func(ctx context.Context) ([]byte, error) {
+				return test.fetch()
+			})))
+
+			if err := client.RefreshCRL(context.Background()); !errors.Is(err, test.refreshErr) {
+				t.Fatalf("RefreshCRL() error = %v, want %v", err, test.refreshErr)
+			}
+			if err := client.VerifySign(testSignedValues(t, pki, "")); !errors.Is(err, test.verifyErr) {
+				t.Fatalf("VerifySign() error = %v, want %v", err, test.verifyErr)
+			}
+		})
+	}
+
+	t.Run("crl from another issuer", func(t *testing.T) {
+		var client = newTestClient(t, pki)
+		if err := client.LoadCRL(other.crl(t, pki.cert)); err == nil {
+			t.Fatal("LoadCRL() accepted a crl signed by another issuer")
+		}
+	})
+}
+
+func TestClient_LoadCRLEvictsCachedCertificate(t *testing.T) {
+	var pki = newTestPKI(t)
+	var client = newTestClient(t, pki)
+
+	if err := client.VerifySign(testSignedValues(t, pki, "")); err != nil {
+		t.Fatal(err)
+	}
+	if size := client.VerifierCache().Stats().Size; size != 1 {
+		t.Fatalf("cache size = %d, want 1", size)
+	}
+
+	if err := client.LoadCRL(pki.crl(t, pki.cert)); err != nil {
+		t.Fatal(err)
+	}
+	if size := client.VerifierCache().Stats().Size; size != 0 {
+		t.Fatalf("cache size after LoadCRL = %d, want 0", size)
+	}
+	if err := client.VerifySign(testSignedValues(t, pki, "")); !errors.Is(err, ErrCertificateRevoked) {
+		t.Fatalf("VerifySign() error = %v, want %v", err, ErrCertificateRevoked)
+	}
+}
+
+// 共享验签缓存时，没有加载吊销列表的 Client 会重新缓存已被吊销的证书，加载了吊销列表的 Client 命中缓存时也需要拒绝。
+func TestClient_RevokedOnSharedCacheHit(t *testing.T) {
+	var pki = newTestPKI(t)
+	var cache = NewVerifierCache(0)
+	var strict = newTestClient(t, pki, WithVerifierCache(cache))
+	var lenient = newTestClient(t, pki, WithVerifierCache(cache))
+
+	if err := strict.LoadCRL(pki.crl(t, pki.cert)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := lenient.VerifySign(testSignedValues(t, pki, "")); err != nil {
+			t.Fatalf("lenient VerifySign() error = %v", err)
+		}
+		if err := strict.VerifySign(testSignedValues(t, pki, "")); !errors.Is(err, ErrCertificateRevoked) {
+			t.Fatalf("strict VerifySign() error = %v, want %v", err, ErrCertificateRevoked)
+		}
+	}
+
+	if hits := cache.Stats().Hits; hits < 2 {
+		t.Fatalf("cache hits = %d, the revocation check on cache hits was not exercised", hits)
+	}
+}
+
+func TestClient_LoadExpiredCRL(t *testing.T) {
+	var pki = newTestPKI(t)
+	var now = time.Now()
+	var client = newTestClient(t, pki, WithClock(ClockFunc(func() time.Time { return now })))
+
+	if err := client.LoadCRL(pki.crl(t, pki.cert)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 超过 NextUpdate 之后加载的吊销列表会被拒绝，之前加载的吊销列表依然有效
+	now = now.Add(2 * time.Hour)
+	if err := client.LoadCRL(pki.crl(t)); !errors.Is(err, ErrCRLExpired) {
+		t.Fatalf("LoadCRL() error = %v, want %v", err, ErrCRLExpired)
+	}
+	if err := client.checkRevoked(pki.cert); !errors.Is(err, ErrCertificateRevoked) {
+		t.Fatalf("checkRevoked() error = %v, want %v", err, ErrCertificateRevoked)
+	}
+}
+
+func TestClient_RevokedByIssuerAndSerial(t *testing.T) {
+	var pki = newTestPKI(t)
+	var other = newTestPKI(t)
+	if pki.cert.SerialNumber.Cmp(other.cert.SerialNumber) != 0 {
+		t.Fatalf("serial numbers differ: %s, %s", pki.cert.SerialNumber, other.cert.SerialNumber)
+	}
+
+	var client = newTestClient(t, pki)
+	if err := client.LoadCRL(pki.crl(t, pki.cert)); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.checkRevoked(pki.cert); !errors.Is(err, ErrCertificateRevoked) {
+		t.Fatalf("checkRevoked() error = %v, want %v", err, ErrCertificateRevoked)
+	}
+	if err := client.checkRevoked(other.cert); err != nil {
+		t.Fatalf("checkRevoked() rejected a certificate with the same serial from another issuer: %v", err)
+	}
+}
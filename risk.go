@@ -0,0 +1,148 @@
+package unionpay
+
+import (
+	"fmt"
+	"github.com/smartwalle/unionpay/internal"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// RiskInfo 风控信息域(riskRateInfo)，银联使用 {key=value&key=value} 的格式，用于风险评估。
+//
+// 各字段的含义以银联的接口规范为准，没有列出的字段可以通过 Extra 上送；为空的字段不会上送。
+//
+// 通过 ConsumeRequest 的 RiskRateInfo 字段设置，编码失败时请求会返回错误，不会发送给银联。
+type RiskInfo struct {
+	ShippingFlag         string `query:"shippingFlag"`         // 是否需要配送
+	ShippingCountryCode  string `query:"shippingCountryCode"`  // 收货地址-国家
+	ShippingProvinceCode string `query:"shippingProvinceCode"` // 收货地址-省
+	ShippingCityCode     string `query:"shippingCityCode"`     // 收货地址-市
+	ShippingDistrictCode string `query:"shippingDistrictCode"` // 收货地址-区
+	ShippingStreet       string `query:"shippingStreet"`       // 收货地址-详细地址
+	ShippingMobile       string `query:"shippingMobile"`       // 收货人手机号
+	CommodityCategory    string `query:"commodityCategory"`    // 商品类别
+	CommodityName        string `query:"commodityName"`        // 商品名称
+	CommodityURL         string `query:"commodityUrl"`         // 商品 URL
+	CommodityUnitPrice   string `query:"commodityUnitPrice"`   // 商品单价，单位分
+	CommodityQty         string `query:"commodityQty"`         // 商品数量
+	UserId               string `query:"userId"`               // 商户端用户 id
+	UserRegisterTime     string `query:"userRegisterTime"`     // 用户注册时间，格式为 YYYYMMDDhhmmss
+	DeviceId             string `query:"deviceID"`             // 设备标识
+	DeviceType           string `query:"deviceType"`           // 设备类型
+	SourceIP             string `query:"sourceIP"`             // 用户 IP
+	UserAgent            string `query:"userAgent"`            // 用户浏览器 User-Agent
+
+	Extra map[string]string `query:"-"` // 其它字段
+}
+
+// RiskInfoFromRequest 从 http.Request 中获取用户 IP 和 User-Agent。
+//
+// 用户 IP 依次从 X-Forwarded-For（第一个地址）、X-Real-IP 和 RemoteAddr 中获取，请确认前置代理会正确设置这些请求头。
+func RiskInfoFromRequest(req *http.Request) *RiskInfo {
+	var info = &RiskInfo{}
+	if req == nil {
+		return info
+	}
+	info.SourceIP = clientIP(req)
+	info.UserAgent = sanitizeBraced(req.UserAgent())
+	return info
+}
+
+func clientIP(req *http.Request) string {
+	if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+		var first, _, _ = strings.Cut(forwarded, ",")
+		if ip := strings.TrimSpace(first); net.ParseIP(ip) != nil {
+			return ip
+		}
+	}
+	if ip := strings.TrimSpace(req.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+// sanitizeBraced 将 &、=、{、} 替换为空格，避免破坏 {key=value} 格式。
+func sanitizeBraced(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '&', '=', '{', '}':
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// Encode 将风控信息编码为 {key=value&key=value} 格式，没有字段时返回空字符串。
+//
+// 键和值中不能包含 &、=、{、}，否则返回 ErrReservedValue。
+func (r *RiskInfo) Encode() (string, error) {
+	if r == nil {
+		return "", nil
+	}
+
+	var values = url.Values{}
+	if err := EncodeRequest(values, r); err != nil {
+		return "", err
+	}
+	for key, value := range r.Extra {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	for key := range values {
+		var value = values.Get(key)
+		if strings.ContainsAny(key, "&={}") || strings.ContainsAny(value, "&={}") {
+			return "", fmt.Errorf("%w: riskRateInfo %s=%s", ErrReservedValue, key, value)
+		}
+	}
+	return internal.EncodeBraced(values), nil
+}
+
+// MarshalText 实现 encoding.TextMarshaler，用于请求结构体中的 RiskRateInfo 字段。
+func (r *RiskInfo) MarshalText() ([]byte, error) {
+	s, err := r.Encode()
+	return []byte(s), err
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，参考 ParseRiskInfo。
+func (r *RiskInfo) UnmarshalText(text []byte) error {
+	info, err := ParseRiskInfo(string(text))
+	if err != nil {
+		return err
+	}
+	*r = *info
+	return nil
+}
+
+// ParseRiskInfo 解析 {key=value&key=value} 格式的风控信息域(riskRateInfo)，RiskInfo 中没有的字段会放到 Extra 中。
+func ParseRiskInfo(s string) (*RiskInfo, error) {
+	values, err := internal.ParseBraced(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var info = &RiskInfo{}
+	var v = reflect.ValueOf(info).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		var name = v.Type().Field(i).Tag.Get("query")
+		if name == "" || name == "-" || !values.Has(name) {
+			continue
+		}
+		v.Field(i).SetString(values.Get(name))
+		values.Del(name)
+	}
+	for key := range values {
+		if info.Extra == nil {
+			info.Extra = make(map[string]string, len(values))
+		}
+		info.Extra[key] = values.Get(key)
+	}
+	return info, nil
+}
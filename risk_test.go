@@ -0,0 +1,131 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestRiskInfo_Encode(t *testing.T) {
+	var tests = []struct {
+		name string
+		info *RiskInfo
+		want string
+		err  error
+	}{
+		{"nil", nil, "", nil},
+		{"empty", &RiskInfo{}, "", nil},
+		{"fields", &RiskInfo{SourceIP: "10.0.0.1", DeviceId: "device", CommodityQty: "2"}, "{commodityQty=2&deviceID=device&sourceIP=10.0.0.1}", nil},
+		{"extra", &RiskInfo{UserId: "u1", Extra: map[string]string{"scene": "web"}}, "{scene=web&userId=u1}", nil},
+		{"& in value", &RiskInfo{CommodityName: "a&b"}, "", ErrReservedValue},
+		{"brace in extra", &RiskInfo{Extra: map[string]string{"scene": "{web}"}}, "", ErrReservedValue},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.info.Encode()
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Encode() error = %v, want %v", err, test.err)
+			}
+			if got != test.want {
+				t.Fatalf("Encode() = %q, want %q", got, test.want)
+			}
+			if err != nil || got == "" {
+				return
+			}
+
+			parsed, err := ParseRiskInfo(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(parsed, test.info) {
+				t.Fatalf("ParseRiskInfo() = %+v, want %+v", parsed, test.info)
+			}
+		})
+	}
+}
+
+func TestRiskInfoFromRequest(t *testing.T) {
+	var tests = []struct {
+		name   string
+		header map[string]string
+		want   string
+	}{
+		{"remote addr", nil, "192.0.2.1"},
+		{"x-forwarded-for", map[string]string{"X-Forwarded-For": "10.0.0.1, 10.0.0.2"}, "10.0.0.1"},
+		{"x-real-ip", map[string]string{"X-Real-IP": "10.0.0.3"}, "10.0.0.3"},
+		{"invalid x-forwarded-for", map[string]string{"X-Forwarded-For": "unknown", "X-Real-IP": "10.0.0.3"}, "10.0.0.3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var req = httptest.NewRequest(http.MethodPost, "/pay", nil)
+			req.Header.Set("User-Agent", "Mozilla/5.0 {a=1&b=2}")
+			for key, value := range test.header {
+				req.Header.Set(key, value)
+			}
+
+			var info = RiskInfoFromRequest(req)
+			if info.SourceIP != test.want {
+				t.Fatalf("SourceIP = %q, want %q", info.SourceIP, test.want)
+			}
+			if _, err := info.Encode(); err != nil {
+				t.Fatalf("Encode() error = %v, User-Agent = %q", err, info.UserAgent)
+			}
+		})
+	}
+}
+
+// RiskRateInfo 会编码之后上送，无法编码时返回错误，不会发送给银联。
+func TestClient_RiskRateInfo(t *testing.T) {
+	var pki = newTestPKI(t)
+
+	var tests = []struct {
+		name string
+		info *RiskInfo
+		want string
+		err  error
+	}{
+		{"not set", nil, "", nil},
+		{"valid", &RiskInfo{SourceIP: "10.0.0.1", UserId: "u1"}, "{sourceIP=10.0.0.1&userId=u1}", nil},
+		{"invalid", &RiskInfo{CommodityName: "a=b"}, "", ErrReservedValue},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int
+			var riskRateInfo string
+			var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+				requests++
+				riskRateInfo = values.Get("riskRateInfo")
+				return testResponse(values, "00")
+			})
+			var client = newTestClient(t, pki, WithGateway(server.URL))
+
+			var req = &AppPaymentRequest{}
+			req.TxnAmt = "1000"
+			req.BackURL = "https://example.com/notify"
+			req.RiskRateInfo = test.info
+			_, err := client.CreateAppPaymentWith(context.Background(), req)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("CreateAppPaymentWith() error = %v, want %v", err, test.err)
+			}
+			if test.err != nil {
+				if requests > 0 {
+					t.Fatal("invalid request was sent to the gateway")
+				}
+				if err = req.Validate(); !errors.Is(err, ErrInvalidRequest) {
+					t.Fatalf("Validate() error = %v, want ValidationError", err)
+				}
+				return
+			}
+			if riskRateInfo != test.want {
+				t.Fatalf("riskRateInfo = %q, want %q", riskRateInfo, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package unionpay
+
+import (
+	"errors"
+	"fmt"
+	"github.com/smartwalle/unionpay/internal"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	AccSplitTypeAmount = "0" // 按金额分账
+	AccSplitTypeRate   = "1" // 按比例分账
+
+	kAccSplitRateBase = 10000 // 分账比例的单位为万分之一
+)
+
+// ErrInvalidAccSplit 分账信息不正确，如分账金额之和与交易金额不一致。
+var ErrInvalidAccSplit = errors.New("invalid account split")
+
+// AccSplit 分账信息中的一个分账商户。
+//
+// Amount 和 Rate 只能设置一个：Amount 为固定金额；Rate 为交易金额的比例，单位为万分之一，如 2550 表示 25.5%。
+type AccSplit struct {
+	MerId  string // 分账商户代码
+	Amount int64  // 分账金额，单位分
+	Rate   int64  // 分账比例，单位为万分之一
+}
+
+// AccSplitData 分账域(accSplitData)，各分账商户的分账金额之和需要等于交易金额。
+//
+// 上送银联时按比例分账的商户会先换算为金额，格式为：
+//
+//	{accSplitMerInfo=[{accSplitAmt=100&accSplitMerId=A},{accSplitAmt=200&accSplitMerId=B}]&accSplitType=0}
+type AccSplitData []AccSplit
+
+// Resolve 根据交易金额(单位分)将按比例分账换算为金额，并校验分账金额之和是否等于交易金额。
+//
+// 按比例换算时向下取整，因取整产生的差额（小于按比例分账的商户数）计入最后一个按比例分账的商户。
+func (d AccSplitData) Resolve(txnAmt string) (AccSplitData, error) {
+	if len(d) == 0 {
+		return nil, nil
+	}
+
+	total, err := strconv.ParseInt(txnAmt, 10, 64)
+	if err != nil || total <= 0 {
+		return nil, fmt.Errorf("%w: invalid txnAmt %q", ErrInvalidAccSplit, txnAmt)
+	}
+
+	var resolved = make(AccSplitData, len(d))
+	var sum, rates int64
+	var last = -1
+	for i, split := range d {
+		if split.MerId == "" {
+			return nil, fmt.Errorf("%w: split %d has no merId", ErrInvalidAccSplit, i)
+		}
+		switch {
+		case split.Amount > 0 && split.Rate == 0:
+		case split.Amount == 0 && split.Rate > 0 && split.Rate <= kAccSplitRateBase:
+			split.Amount = total * split.Rate / kAccSplitRateBase
+			last = i
+			rates++
+		default:
+			return nil, fmt.Errorf("%w: split %s must have either a positive amount or a rate in (0, %d]", ErrInvalidAccSplit, split.MerId, kAccSplitRateBase)
+		}
+		resolved[i] = AccSplit{MerId: split.MerId, Amount: split.Amount}
+		sum += split.Amount
+	}
+
+	if diff := total - sum; last >= 0 && diff > 0 && diff < rates {
+		resolved[last].Amount += diff
+		sum += diff
+	}
+	if sum != total {
+		return nil, fmt.Errorf("%w: splits add up to %d, txnAmt is %d", ErrInvalidAccSplit, sum, total)
+	}
+	return resolved, nil
+}
+
+// Validate 校验分账金额之和是否等于交易金额(单位分)，参考 Resolve。
+func (d AccSplitData) Validate(txnAmt string) error {
+	_, err := d.Resolve(txnAmt)
+	return err
+}
+
+// Encode 根据交易金额(单位分)将分账信息编码为银联的格式，没有分账商户时返回空字符串。
+func (d AccSplitData) Encode(txnAmt string) (string, error) {
+	resolved, err := d.Resolve(txnAmt)
+	if err != nil || len(resolved) == 0 {
+		return "", err
+	}
+
+	var infos = make([]string, 0, len(resolved))
+	for _, split := range resolved {
+		if strings.ContainsAny(split.MerId, "&={}[],") {
+			return "", fmt.Errorf("%w: invalid merId %q", ErrInvalidAccSplit, split.MerId)
+		}
+		var info = url.Values{}
+		info.Set("accSplitMerId", split.MerId)
+		info.Set("accSplitAmt", strconv.FormatInt(split.Amount, 10))
+		infos = append(infos, internal.EncodeBraced(info))
+	}
+
+	var values = url.Values{}
+	values.Set("accSplitType", AccSplitTypeAmount)
+	values.Set("accSplitMerInfo", "["+strings.Join(infos, ",")+"]")
+	return internal.EncodeBraced(values), nil
+}
+
+// ParseAccSplitData 解析银联返回的分账域(accSplitData)，按比例分账时 accSplitRate 会解析到 Rate。
+func ParseAccSplitData(s string) (AccSplitData, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	values, err := internal.ParseBraced(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos = []string{s}
+	if merInfo := values.Get("accSplitMerInfo"); merInfo != "" {
+		if len(merInfo) < 2 || merInfo[0] != '[' || merInfo[len(merInfo)-1] != ']' {
+			return nil, fmt.Errorf("invalid accSplitMerInfo %q", merInfo)
+		}
+		infos = splitBracedList(merInfo[1 : len(merInfo)-1])
+	}
+
+	var data = make(AccSplitData, 0, len(infos))
+	for _, info := range infos {
+		values, err = internal.ParseBraced(info)
+		if err != nil {
+			return nil, err
+		}
+
+		var split = AccSplit{MerId: values.Get("accSplitMerId")}
+		if amount := values.Get("accSplitAmt"); amount != "" {
+			if split.Amount, err = strconv.ParseInt(amount, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid accSplitAmt %q", amount)
+			}
+		}
+		if rate := values.Get("accSplitRate"); rate != "" {
+			if split.Rate, err = strconv.ParseInt(rate, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid accSplitRate %q", rate)
+			}
+		}
+		data = append(data, split)
+	}
+	return data, nil
+}
+
+// splitBracedList 按照 , 切分 {..},{..} 格式的列表，{} 中的 , 不作为分隔符。
+func splitBracedList(s string) []string {
+	var items []string
+	var depth = 0
+	var start = 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if item := strings.TrimSpace(s[start:]); item != "" {
+		items = append(items, item)
+	}
+	return items
+}
+
+// ParseAccSplitData 解析分账域(accSplitData)，参考包级别的 ParseAccSplitData 函数。
+func (n *PaymentNotification) ParseAccSplitData() (AccSplitData, error) {
+	return ParseAccSplitData(n.AccSplitData)
+}
+
+// ParseAccSplitData 解析分账域(accSplitData)，参考包级别的 ParseAccSplitData 函数。
+func (t *Transaction) ParseAccSplitData() (AccSplitData, error) {
+	return ParseAccSplitData(t.AccSplitData)
+}
+
+// encodeAccSplitData 根据请求参数中的交易金额编码分账域，需要在 txnAmt 确定之后调用。
+func encodeAccSplitData(values url.Values, data AccSplitData) error {
+	s, err := data.Encode(values.Get("txnAmt"))
+	if err != nil {
+		return err
+	}
+	if s != "" {
+		values.Set("accSplitData", s)
+	}
+	return nil
+}
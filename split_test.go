@@ -0,0 +1,263 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccSplitData_Resolve(t *testing.T) {
+	var tests = []struct {
+		name   string
+		data   AccSplitData
+		txnAmt string
+		want   AccSplitData
+		err    bool
+	}{
+		{"empty", nil, "1000", nil, false},
+		{"amounts", AccSplitData{{MerId: "A", Amount: 300}, {MerId: "B", Amount: 700}}, "1000", AccSplitData{{MerId: "A", Amount: 300}, {MerId: "B", Amount: 700}}, false},
+		{"rates", AccSplitData{{MerId: "A", Rate: 2550}, {MerId: "B", Rate: 7450}}, "1000", AccSplitData{{MerId: "A", Amount: 255}, {MerId: "B", Amount: 745}}, false},
+		{"rounding goes to last rate", AccSplitData{{MerId: "A", Rate: 3333}, {MerId: "B", Rate: 3333}, {MerId: "C", Rate: 3334}}, "100", AccSplitData{{MerId: "A", Amount: 33}, {MerId: "B", Amount: 33}, {MerId: "C", Amount: 34}}, false},
+		{"rounding skips amounts", AccSplitData{{MerId: "A", Rate: 2500}, {MerId: "B", Rate: 2500}, {MerId: "C", Amount: 5}}, "10", AccSplitData{{MerId: "A", Amount: 2}, {MerId: "B", Amount: 3}, {MerId: "C", Amount: 5}}, false},
+		{"shortfall beyond rounding", AccSplitData{{MerId: "A", Rate: 5000}, {MerId: "B", Amount: 1}}, "3", nil, true},
+		{"shortfall with many amounts", AccSplitData{{MerId: "A", Rate: 5000}, {MerId: "B", Amount: 1}, {MerId: "C", Amount: 1}}, "5", nil, true},
+		{"mixed", AccSplitData{{MerId: "A", Amount: 100}, {MerId: "B", Rate: 10000}}, "1000", nil, true},
+		{"sum less than txnAmt", AccSplitData{{MerId: "A", Amount: 300}, {MerId: "B", Amount: 600}}, "1000", nil, true},
+		{"sum more than txnAmt", AccSplitData{{MerId: "A", Amount: 300}, {MerId: "B", Amount: 800}}, "1000", nil, true},
+		{"missing merId", AccSplitData{{Amount: 1000}}, "1000", nil, true},
+		{"amount and rate", AccSplitData{{MerId: "A", Amount: 500, Rate: 5000}}, "1000", nil, true},
+		{"rate over 100%", AccSplitData{{MerId: "A", Rate: 10001}}, "1000", nil, true},
+		{"negative amount", AccSplitData{{MerId: "A", Amount: -1000}}, "1000", nil, true},
+		{"invalid txnAmt", AccSplitData{{MerId: "A", Amount: 1000}}, "10.00", nil, true},
+		{"zero txnAmt", AccSplitData{{MerId: "A", Amount: 0}}, "0", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.data.Resolve(test.txnAmt)
+			if (err != nil) != test.err {
+				t.Fatalf("Resolve() error = %v, want error %v", err, test.err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidAccSplit) {
+				t.Fatalf("Resolve() error = %v, want ErrInvalidAccSplit", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("Resolve() = %+v, want %+v", got, test.want)
+			}
+			if err = test.data.Validate(test.txnAmt); (err != nil) != test.err {
+				t.Fatalf("Validate() error = %v, want error %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestAccSplitData_Encode(t *testing.T) {
+	var tests = []struct {
+		name   string
+		data   AccSplitData
+		txnAmt string
+		want   string
+		err    bool
+	}{
+		{"empty", nil, "1000", "", false},
+		{"single", AccSplitData{{MerId: "A", Amount: 1000}}, "1000", "{accSplitMerInfo=[{accSplitAmt=1000&accSplitMerId=A}]&accSplitType=0}", false},
+		{"rates", AccSplitData{{MerId: "A", Rate: 2500}, {MerId: "B", Rate: 7500}}, "1000", "{accSplitMerInfo=[{accSplitAmt=250&accSplitMerId=A},{accSplitAmt=750&accSplitMerId=B}]&accSplitType=0}", false},
+		{"invalid merId", AccSplitData{{MerId: "A,B", Amount: 1000}}, "1000", "", true},
+		{"sum mismatch", AccSplitData{{MerId: "A", Amount: 999}}, "1000", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.data.Encode(test.txnAmt)
+			if (err != nil) != test.err {
+				t.Fatalf("Encode() error = %v, want error %v", err, test.err)
+			}
+			if got != test.want {
+				t.Fatalf("Encode() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseAccSplitData(t *testing.T) {
+	var tests = []struct {
+		name string
+		s    string
+		want AccSplitData
+		err  bool
+	}{
+		{"empty", "", nil, false},
+		{"amounts", "{accSplitMerInfo=[{accSplitAmt=250&accSplitMerId=A},{accSplitAmt=750&accSplitMerId=B}]&accSplitType=0}", AccSplitData{{MerId: "A", Amount: 250}, {MerId: "B", Amount: 750}}, false},
+		{"rates", "{accSplitMerInfo=[{accSplitMerId=A&accSplitRate=2500}, {accSplitMerId=B&accSplitRate=7500}]&accSplitType=1}", AccSplitData{{MerId: "A", Rate: 2500}, {MerId: "B", Rate: 7500}}, false},
+		{"type before list", "{accSplitType=0&accSplitMerInfo=[{accSplitAmt=1000&accSplitMerId=A}]}", AccSplitData{{MerId: "A", Amount: 1000}}, false},
+		{"single merchant", "{accSplitAmt=1000&accSplitMerId=A}", AccSplitData{{MerId: "A", Amount: 1000}}, false},
+		{"not braced", "accSplitMerId=A", nil, true},
+		{"invalid list", "{accSplitMerInfo={accSplitAmt=1000&accSplitMerId=A}}", nil, true},
+		{"invalid amount", "{accSplitMerInfo=[{accSplitAmt=10.00&accSplitMerId=A}]}", nil, true},
+		{"invalid rate", "{accSplitMerInfo=[{accSplitMerId=A&accSplitRate=x}]}", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseAccSplitData(test.s)
+			if (err != nil) != test.err {
+				t.Fatalf("ParseAccSplitData() error = %v, want error %v", err, test.err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("ParseAccSplitData() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSplitBracedList(t *testing.T) {
+	var tests = []struct {
+		s    string
+		want []string
+	}{
+		{"", nil},
+		{"{a=1}", []string{"{a=1}"}},
+		{"{a=1},{b=2}", []string{"{a=1}", "{b=2}"}},
+		{"{a=1} , {b=2},", []string{"{a=1}", "{b=2}"}},
+		{"{a={b,c}},{d=1}", []string{"{a={b,c}}", "{d=1}"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			if got := splitBracedList(test.s); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("splitBracedList() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// 分账域根据最终的 txnAmt 编码之后上送，分账金额之和与 txnAmt 不一致时不会发送给银联。
+func TestClient_AccSplitData(t *testing.T) {
+	var pki = newTestPKI(t)
+	var now = time.Date(2026, 10, 19, 12, 0, 0, 0, kBeijing)
+	var data = AccSplitData{{MerId: "A", Rate: 2500}, {MerId: "B", Rate: 7500}}
+
+	var tests = []struct {
+		name   string
+		txnAmt string
+		opts   []CallOption
+		want   string
+		err    bool
+	}{
+		{"txnAmt of request", "1000", nil, "{accSplitMerInfo=[{accSplitAmt=250&accSplitMerId=A},{accSplitAmt=750&accSplitMerId=B}]&accSplitType=0}", false},
+		{"request wins over payload", "1000", []CallOption{WithPayload(NewPayload().AddParam("txnAmt", "2000"))}, "{accSplitMerInfo=[{accSplitAmt=250&accSplitMerId=A},{accSplitAmt=750&accSplitMerId=B}]&accSplitType=0}", false},
+		{"rounding", "999", nil, "{accSplitMerInfo=[{accSplitAmt=249&accSplitMerId=A},{accSplitAmt=750&accSplitMerId=B}]&accSplitType=0}", false},
+		{"invalid txnAmt", "0", nil, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int
+			var accSplitData string
+			var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+				requests++
+				accSplitData = values.Get("accSplitData")
+				if values.Get("txnTime") != "20261019120000" {
+					t.Errorf("txnTime = %s, want 20261019120000", values.Get("txnTime"))
+				}
+				var rValues = testResponse(values, "00")
+				rValues.Set("accSplitData", accSplitData)
+				return rValues
+			})
+			var client = newTestClient(t, pki, WithGateway(server.URL), WithClock(ClockFunc(func() time.Time { return now })))
+
+			var req = &AppPaymentRequest{}
+			req.TxnAmt = test.txnAmt
+			req.BackURL = "https://example.com/notify"
+			req.AccSplitData = data
+			_, err := client.CreateAppPaymentWith(context.Background(), req, test.opts...)
+			if test.err {
+				if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), "txnAmt") {
+					t.Fatalf("CreateAppPaymentWith() error = %v, want ValidationError about txnAmt", err)
+				}
+				if requests > 0 {
+					t.Fatal("invalid request was sent to the gateway")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if accSplitData != test.want {
+				t.Fatalf("accSplitData = %q, want %q", accSplitData, test.want)
+			}
+		})
+	}
+}
+
+// 分账金额之和与 txnAmt 不一致时返回 ValidationError，不会发送给银联。
+func TestClient_AccSplitDataMismatch(t *testing.T) {
+	var pki = newTestPKI(t)
+	var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		t.Error("invalid request was sent to the gateway")
+		return testResponse(values, "00")
+	})
+	var client = newTestClient(t, pki, WithGateway(server.URL))
+
+	var req = &AppPaymentRequest{}
+	req.TxnAmt = "1000"
+	req.BackURL = "https://example.com/notify"
+	req.AccSplitData = AccSplitData{{MerId: "A", Amount: 300}, {MerId: "B", Amount: 600}}
+	_, err := client.CreateAppPaymentWith(context.Background(), req)
+	if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), "accSplitData") {
+		t.Fatalf("CreateAppPaymentWith() error = %v, want ValidationError about accSplitData", err)
+	}
+}
+
+// 交易状态查询的应答和支付通知中的分账域能够通过签名验证并解析。
+func TestClient_ParseAccSplitData(t *testing.T) {
+	var pki = newTestPKI(t)
+	var accSplitData = "{accSplitMerInfo=[{accSplitAmt=250&accSplitMerId=A},{accSplitAmt=750&accSplitMerId=B}]&accSplitType=0}"
+	var want = AccSplitData{{MerId: "A", Amount: 250}, {MerId: "B", Amount: 750}}
+
+	var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		var rValues = testQueryGateway(t, "00", "00")(w, api, values)
+		rValues.Set("accSplitData", accSplitData)
+		return rValues
+	})
+	var client = newTestClient(t, pki, WithGateway(server.URL))
+
+	transaction, err := client.GetTransaction(context.Background(), "20261019120000abcdef0001", "20261019120000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction.AccSplitData != accSplitData {
+		t.Fatalf("AccSplitData = %q, want %q", transaction.AccSplitData, accSplitData)
+	}
+	got, err := transaction.ParseAccSplitData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseAccSplitData() = %+v, want %+v", got, want)
+	}
+
+	var values = url.Values{}
+	values.Set("merId", kTestMerchantId)
+	values.Set("orderId", "20261019120000abcdef0001")
+	values.Set("txnType", "01")
+	values.Set("txnAmt", "1000")
+	values.Set("respCode", "00")
+	values.Set("accSplitData", accSplitData)
+	notification, err := client.DecodeNotification(pki.sign(t, values, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = notification.(*PaymentNotification).ParseAccSplitData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("PaymentNotification.ParseAccSplitData() = %+v, want %+v", got, want)
+	}
+}
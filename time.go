@@ -0,0 +1,117 @@
+package unionpay
+
+import (
+	"time"
+)
+
+const kTimeFormat = "20060102150405"
+
+// kBeijing 银联各接口中的时间均为北京时间。
+var kBeijing = loadBeijing()
+
+func loadBeijing() *time.Location {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return time.FixedZone("CST", 8*60*60)
+	}
+	return loc
+}
+
+// settleCutoff 返回 txnTime 所在清算日的截止时间。
+//
+// 银联的清算日为前一日 23:00 至当日 23:00（北京时间）。
+func settleCutoff(txnTime string) (time.Time, error) {
+	t, err := time.ParseInLocation(kTimeFormat, txnTime, kBeijing)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var cutoff = time.Date(t.Year(), t.Month(), t.Day(), 23, 0, 0, 0, kBeijing)
+	if !t.Before(cutoff) {
+		cutoff = cutoff.AddDate(0, 0, 1)
+	}
+	return cutoff, nil
+}
+
+// Clock 用于获取当前时间，可以通过 WithClock 替换，便于测试。
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc 将函数转换为 Clock。
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// WithClock 设置获取当前时间的方式，订单发送时间(txnTime)、消费撤销的清算日判断、订单过期检查等都会使用该时间。
+func WithClock(clock Clock) OptionFunc {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+func (c *Client) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
+
+// txnTime 返回当前时间对应的订单发送时间(txnTime)。
+func (c *Client) txnTime() string {
+	return FormatTime(c.now())
+}
+
+// FormatTime 将时间转换为北京时间并格式化为 YYYYMMDDhhmmss，用于 txnTime、payTimeout 等字段。
+func FormatTime(t time.Time) string {
+	return t.In(kBeijing).Format(kTimeFormat)
+}
+
+// ParseTxnTime 解析 YYYYMMDDhhmmss 格式（北京时间）的订单发送时间(txnTime)。
+func ParseTxnTime(s string) (time.Time, error) {
+	return time.ParseInLocation(kTimeFormat, s, kBeijing)
+}
+
+// ParseTraceTime 解析 MMDDhhmmss 格式（北京时间）的交易传输时间(traceTime)。
+//
+// traceTime 中没有年份，会选择使结果最接近 ref 的年份，ref 一般为该交易的订单发送时间。
+func ParseTraceTime(s string, ref time.Time) (time.Time, error) {
+	return parseWithoutYear("0102150405", s, ref)
+}
+
+// ParseSettleDate 解析 MMDD 格式的清算日期(settleDate)，返回该日 00:00（北京时间）。
+//
+// settleDate 中没有年份，会选择使结果最接近 ref 的年份，ref 一般为该交易的订单发送时间。
+func ParseSettleDate(s string, ref time.Time) (time.Time, error) {
+	return parseWithoutYear("0102", s, ref)
+}
+
+func parseWithoutYear(layout, s string, ref time.Time) (time.Time, error) {
+	t, err := time.ParseInLocation("2006"+layout, "2000"+s, kBeijing)
+	if err != nil {
+		return time.Time{}, err
+	}
+	ref = ref.In(kBeijing)
+
+	var result time.Time
+	for _, year := range []int{ref.Year() - 1, ref.Year(), ref.Year() + 1} {
+		// 2 月 29 日在非闰年时无效
+		if t.Month() == time.February && t.Day() == 29 && time.Date(year, time.March, 0, 0, 0, 0, 0, kBeijing).Day() != 29 {
+			continue
+		}
+		var candidate = time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, kBeijing)
+		if result.IsZero() || absDuration(candidate.Sub(ref)) < absDuration(result.Sub(ref)) {
+			result = candidate
+		}
+	}
+	return result, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,159 @@
+package unionpay
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSettleCutoff(t *testing.T) {
+	var tests = []struct {
+		txnTime string
+		want    string
+	}{
+		{"20261019000000", "20261019230000"},
+		{"20261019120000", "20261019230000"},
+		{"20261019225959", "20261019230000"},
+		{"20261019230000", "20261020230000"},
+		{"20261019235959", "20261020230000"},
+		{"20261231233000", "20270101230000"},
+		{"20280228231500", "20280229230000"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.txnTime, func(t *testing.T) {
+			cutoff, err := settleCutoff(test.txnTime)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := FormatTime(cutoff); got != test.want {
+				t.Fatalf("settleCutoff() = %s, want %s", got, test.want)
+			}
+		})
+	}
+
+	if _, err := settleCutoff("2026-10-19"); err == nil {
+		t.Fatal("settleCutoff() accepted an invalid txnTime")
+	}
+}
+
+func TestIsRevocable(t *testing.T) {
+	var tests = []struct {
+		name       string
+		txnTime    string
+		settleDate string
+		now        time.Time
+		want       bool
+	}{
+		{"same day", "20261019120000", "1019", time.Date(2026, 10, 19, 18, 0, 0, 0, kBeijing), true},
+		{"after cutoff", "20261019120000", "1019", time.Date(2026, 10, 19, 23, 0, 0, 0, kBeijing), false},
+		{"after 23:00 belongs to next settle day", "20261019231000", "1020", time.Date(2026, 10, 20, 9, 0, 0, 0, kBeijing), true},
+		{"settleDate of another day", "20261019120000", "1020", time.Date(2026, 10, 19, 18, 0, 0, 0, kBeijing), false},
+		{"without settleDate", "20261019120000", "", time.Date(2026, 10, 19, 18, 0, 0, 0, kBeijing), true},
+		{"utc clock", "20261019120000", "1019", time.Date(2026, 10, 19, 14, 59, 0, 0, time.UTC), true},
+		{"utc clock after cutoff", "20261019120000", "1019", time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var transaction = &Transaction{TxnTime: test.txnTime, SettleDate: test.settleDate}
+			got, err := isRevocable(transaction, test.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Fatalf("isRevocable() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	var tests = []struct {
+		time time.Time
+		want string
+	}{
+		{time.Date(2026, 10, 19, 12, 0, 0, 0, kBeijing), "20261019120000"},
+		{time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC), "20261019200000"},
+		{time.Date(2026, 12, 31, 16, 30, 0, 0, time.UTC), "20270101003000"},
+		{time.Date(2026, 10, 19, 12, 0, 0, 0, time.FixedZone("PDT", -7*60*60)), "20261020030000"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			if got := FormatTime(test.time); got != test.want {
+				t.Fatalf("FormatTime() = %s, want %s", got, test.want)
+			}
+			parsed, err := ParseTxnTime(test.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !parsed.Equal(test.time) {
+				t.Fatalf("ParseTxnTime() = %s, want %s", parsed, test.time)
+			}
+		})
+	}
+}
+
+func TestParseWithoutYear(t *testing.T) {
+	var ref = time.Date(2026, 10, 19, 12, 0, 0, 0, kBeijing)
+
+	var tests = []struct {
+		name  string
+		parse func(s string, ref time.Time) (time.Time, error)
+		s     string
+		ref   time.Time
+		want  time.Time
+		err   bool
+	}{
+		{"trace time", ParseTraceTime, "1019120005", ref, time.Date(2026, 10, 19, 12, 0, 5, 0, kBeijing), false},
+		{"trace time of previous year", ParseTraceTime, "1231235959", time.Date(2027, 1, 1, 0, 0, 10, 0, kBeijing), time.Date(2026, 12, 31, 23, 59, 59, 0, kBeijing), false},
+		{"trace time of next year", ParseTraceTime, "0101000001", time.Date(2026, 12, 31, 23, 59, 0, 0, kBeijing), time.Date(2027, 1, 1, 0, 0, 1, 0, kBeijing), false},
+		{"settle date", ParseSettleDate, "1020", ref, time.Date(2026, 10, 20, 0, 0, 0, 0, kBeijing), false},
+		{"settle date of next year", ParseSettleDate, "0101", time.Date(2026, 12, 31, 23, 30, 0, 0, kBeijing), time.Date(2027, 1, 1, 0, 0, 0, 0, kBeijing), false},
+		{"leap day", ParseSettleDate, "0229", time.Date(2028, 2, 28, 23, 30, 0, 0, kBeijing), time.Date(2028, 2, 29, 0, 0, 0, 0, kBeijing), false},
+		{"leap day skips non-leap years", ParseSettleDate, "0229", time.Date(2027, 3, 1, 0, 0, 0, 0, kBeijing), time.Date(2028, 2, 29, 0, 0, 0, 0, kBeijing), false},
+		{"utc ref", ParseSettleDate, "0101", time.Date(2026, 12, 31, 16, 30, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, kBeijing), false},
+		{"invalid", ParseSettleDate, "1332", ref, time.Time{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.parse(test.s, test.ref)
+			if (err != nil) != test.err {
+				t.Fatalf("parse(%q) error = %v, want error %v", test.s, err, test.err)
+			}
+			if !test.err && !got.Equal(test.want) {
+				t.Fatalf("parse(%q) = %s, want %s", test.s, got, test.want)
+			}
+		})
+	}
+}
+
+// 订单发送时间(txnTime)和商户订单号均使用 WithClock 设置的时间，并转换为北京时间。
+func TestClient_TxnTimeUsesClock(t *testing.T) {
+	var pki = newTestPKI(t)
+	var now = time.Date(2026, 10, 19, 16, 30, 0, 0, time.UTC)
+
+	var txnTime, orderId string
+	var server = newTestGateway(t, pki, func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		txnTime = values.Get("txnTime")
+		orderId = values.Get("orderId")
+		return testResponse(values, "00")
+	})
+	var client = newTestClient(t, pki, WithGateway(server.URL), WithClock(ClockFunc(func() time.Time { return now })))
+
+	refund, err := client.Refund(context.Background(), "123456789012345678901", "", "1000", "https://example.com/notify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txnTime != "20261020003000" || refund.TxnTime != txnTime {
+		t.Fatalf("txnTime = %s (response %s), want 20261020003000", txnTime, refund.TxnTime)
+	}
+	if !strings.HasPrefix(orderId, txnTime) {
+		t.Fatalf("orderId = %s, want prefix %s", orderId, txnTime)
+	}
+}
@@ -0,0 +1,185 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TransactionState 被查询交易（原交易）的状态。
+type TransactionState int
+
+const (
+	TransactionStateUnknown          TransactionState = iota // 无法确定，如网络错误、报文格式错误等，需要再次查询
+	TransactionStateSucceeded                                // 交易成功
+	TransactionStateFailed                                   // 交易失败
+	TransactionStatePending                                  // 交易处理中，需要再次查询
+	TransactionStateNotFound                                 // 查无此交易
+	TransactionStatePaidAfterTimeout                         // 扣款成功但交易超过规定支付时间(42)，需要发起退货
+)
+
+func (s TransactionState) String() string {
+	switch s {
+	case TransactionStateSucceeded:
+		return "Succeeded"
+	case TransactionStateFailed:
+		return "Failed"
+	case TransactionStatePending:
+		return "Pending"
+	case TransactionStateNotFound:
+		return "NotFound"
+	case TransactionStatePaidAfterTimeout:
+		return "PaidAfterTimeout"
+	}
+	return "Unknown"
+}
+
+// IsFinal 是否为最终状态（成功、失败或者超时扣款）。
+func (s TransactionState) IsFinal() bool {
+	return s == TransactionStateSucceeded || s == TransactionStateFailed || s == TransactionStatePaidAfterTimeout
+}
+
+// ErrTransactionNotFinal 在规定的时间内没有查询到交易的最终状态。
+var ErrTransactionNotFinal = errors.New("transaction did not reach a final state")
+
+// ResolveTransaction 根据交易状态查询接口(GetTransaction)的返回结果判断被查询交易的状态。
+//
+// 应答码(respCode)表示的是查询交易本身的应答：
+//
+// respCode 为 34 时表示查无此交易；为 03、04、05 时表示查询交易本身状态未明，需要再次查询；为其它失败应答码时无法确定被查询交易的状态。
+//
+// respCode 为 00 时，根据原交易应答码(origRespCode)判断被查询交易的状态：
+//
+// origRespCode 为 00 或 A6 时表示交易成功；为 42 时表示扣款成功但交易超过规定支付时间，持卡人已被扣款，需要发起退货；
+// 为 03、04、05、70、74 时表示交易处理中，需要再次查询；为其它应答码时表示交易失败。
+func ResolveTransaction(transaction *Transaction, err error) TransactionState {
+	if err == nil && transaction != nil {
+		err = transaction.err()
+	}
+	if err != nil {
+		var rErr Error
+		if !errors.As(err, &rErr) {
+			return TransactionStateUnknown
+		}
+
+		switch {
+		case rErr.Code == CodeNotFound:
+			return TransactionStateNotFound
+		case rErr.IsPending():
+			return TransactionStatePending
+		}
+		return TransactionStateUnknown
+	}
+
+	if transaction == nil {
+		return TransactionStateUnknown
+	}
+
+	var origCode = Code(transaction.OrigRespCode)
+	switch {
+	case origCode == "":
+		return TransactionStateUnknown
+	case origCode.succeeded():
+		return TransactionStateSucceeded
+	case origCode.IsPaidAfterPayTimeout():
+		return TransactionStatePaidAfterTimeout
+	case origCode.IsPending():
+		return TransactionStatePending
+	}
+	return TransactionStateFailed
+}
+
+// State 返回被查询交易的状态，参考 ResolveTransaction。
+func (t *Transaction) State() TransactionState {
+	return ResolveTransaction(t, nil)
+}
+
+type waitOptions struct {
+	interval    time.Duration
+	maxInterval time.Duration
+	timeout     time.Duration
+}
+
+type WaitOption func(opts *waitOptions)
+
+// WithWaitInterval 设置查询间隔，首次查询在 interval 之后进行，之后每次间隔翻倍，最长不超过 maxInterval。
+//
+// 默认首次间隔为 5 秒，最长间隔为 5 分钟。
+func WithWaitInterval(interval, maxInterval time.Duration) WaitOption {
+	return func(opts *waitOptions) {
+		if interval > 0 {
+			opts.interval = interval
+		}
+		if maxInterval > 0 {
+			opts.maxInterval = maxInterval
+		}
+	}
+}
+
+// WithWaitTimeout 设置查询的总时长，默认为 1 小时，超过该时长依然没有查询到最终状态时返回 ErrTransactionNotFinal。
+func WithWaitTimeout(timeout time.Duration) WaitOption {
+	return func(opts *waitOptions) {
+		if timeout > 0 {
+			opts.timeout = timeout
+		}
+	}
+}
+
+// WaitForFinalState 轮询交易状态查询接口(GetTransaction)，直到查询到被查询交易的最终状态（成功或者失败）。
+//
+// 银联建议对状态未明的交易（应答码为 03、04、05）间隔一段时间之后再次发起查询，间隔时间逐步增加，查询总时长有上限，参考：https://open.unionpay.com/tjweb/support/faq/mchlist?id=77
+//
+// 在 ctx 结束或者超过 WithWaitTimeout 设置的总时长时停止查询，并返回最后一次查询的结果和状态。
+//
+// orderId：商户订单号。
+//
+// txnTime：订单发送时间，格式为 YYYYMMDDhhmmss，orderId 和 txnTime 组成唯一订单信息。
+func (c *Client) WaitForFinalState(ctx context.Context, orderId, txnTime string, opts ...WaitOption) (*Transaction, TransactionState, error) {
+	var nOpts = &waitOptions{
+		interval:    5 * time.Second,
+		maxInterval: 5 * time.Minute,
+		timeout:     time.Hour,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(nOpts)
+		}
+	}
+
+	var deadline = time.Now().Add(nOpts.timeout)
+	var interval = nOpts.interval
+
+	var timer = time.NewTimer(interval)
+	defer timer.Stop()
+
+	var transaction *Transaction
+	var state = TransactionStateUnknown
+	for {
+		select {
+		case <-ctx.Done():
+			return transaction, state, ctx.Err()
+		case <-timer.C:
+		}
+
+		var nTransaction, err = c.GetTransaction(ctx, orderId, txnTime)
+		if nTransaction != nil {
+			transaction = nTransaction
+		}
+		state = ResolveTransaction(nTransaction, err)
+		if state.IsFinal() {
+			return transaction, state, nil
+		}
+
+		if interval *= 2; interval > nOpts.maxInterval {
+			interval = nOpts.maxInterval
+		}
+		var remaining = time.Until(deadline)
+		if remaining <= 0 {
+			return transaction, state, ErrTransactionNotFinal
+		}
+		if interval > remaining {
+			interval = remaining
+		}
+		timer.Reset(interval)
+	}
+}
@@ -0,0 +1,154 @@
+package unionpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCode_Classification(t *testing.T) {
+	var tests = []struct {
+		code             Code
+		pending          bool
+		retryable        bool
+		paidAfterTimeout bool
+		finalFailure     bool
+		success          bool
+		partialSuccess   bool
+	}{
+		{CodeSuccess, false, false, false, false, true, false},
+		{CodePartialSuccess, false, false, false, false, false, true},
+		{CodeFailure, false, false, false, true, false, false},
+		{CodeTimeout, true, false, false, false, false, false},
+		{CodeUnknownStatus, true, false, false, false, false, false},
+		{CodeAccepted, true, false, false, false, false, false},
+		{CodeSystemBusy, false, true, false, false, false, false},
+		{CodeTooFrequent, false, true, false, false, false, false},
+		{CodeNotFound, false, false, false, true, false, false},
+		{CodePaidAfterPayTimeout, false, false, true, false, false, false},
+		{CodeWaitingInput, true, false, false, false, false, false},
+		{CodeDebitedUnknown, true, false, false, false, false, false},
+		{CodeInsufficientFunds, false, false, false, true, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.code), func(t *testing.T) {
+			if got := test.code.IsPending(); got != test.pending {
+				t.Errorf("IsPending() = %v, want %v", got, test.pending)
+			}
+			if got := test.code.IsRetryable(); got != test.retryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, test.retryable)
+			}
+			if got := test.code.IsPaidAfterPayTimeout(); got != test.paidAfterTimeout {
+				t.Errorf("IsPaidAfterPayTimeout() = %v, want %v", got, test.paidAfterTimeout)
+			}
+			if got := test.code.IsFinalFailure(); got != test.finalFailure {
+				t.Errorf("IsFinalFailure() = %v, want %v", got, test.finalFailure)
+			}
+			if got := test.code.IsSuccess(); got != test.success {
+				t.Errorf("IsSuccess() = %v, want %v", got, test.success)
+			}
+			if got := test.code.IsPartialSuccess(); got != test.partialSuccess {
+				t.Errorf("IsPartialSuccess() = %v, want %v", got, test.partialSuccess)
+			}
+		})
+	}
+}
+
+func testTransaction(origRespCode string) *Transaction {
+	return &Transaction{Error: Error{Code: CodeSuccess}, OrigRespCode: origRespCode}
+}
+
+func TestResolveTransaction(t *testing.T) {
+	var tests = []struct {
+		name        string
+		transaction *Transaction
+		err         error
+		want        TransactionState
+	}{
+		{"success", testTransaction("00"), nil, TransactionStateSucceeded},
+		{"partial success", testTransaction("A6"), nil, TransactionStateSucceeded},
+		{"paid after timeout", testTransaction("42"), nil, TransactionStatePaidAfterTimeout},
+		{"waiting for input", testTransaction("70"), nil, TransactionStatePending},
+		{"debited, settlement unknown", testTransaction("74"), nil, TransactionStatePending},
+		{"accepted", testTransaction("05"), nil, TransactionStatePending},
+		{"declined", testTransaction("30"), nil, TransactionStateFailed},
+		{"no origRespCode", testTransaction(""), nil, TransactionStateUnknown},
+		{"not found", nil, Error{Code: CodeNotFound}, TransactionStateNotFound},
+		{"query pending", nil, Error{Code: CodeTimeout}, TransactionStatePending},
+		{"query failed", nil, Error{Code: CodeFormatError}, TransactionStateUnknown},
+		{"transport error", nil, errors.New("connection reset"), TransactionStateUnknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ResolveTransaction(test.transaction, test.err); got != test.want {
+				t.Fatalf("ResolveTransaction() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+// testQueryGateway 交易状态查询接口返回 respCode，respCode 为 00 时返回 origRespCode。
+func testQueryGateway(t *testing.T, respCode, origRespCode string) testGatewayFunc {
+	return func(w http.ResponseWriter, api string, values url.Values) url.Values {
+		if api != kQueryTrans {
+			t.Errorf("unexpected api %s", api)
+		}
+		var rValues = testResponse(values, respCode)
+		if respCode == "00" {
+			rValues.Set("origRespCode", origRespCode)
+			rValues.Set("origRespMsg", "test "+origRespCode)
+			rValues.Set("queryId", "123456789012345678901")
+		}
+		return rValues
+	}
+}
+
+func TestClient_CheckOrderExpiry(t *testing.T) {
+	var pki = newTestPKI(t)
+	var txnTime = "20261019120000"
+	var sent, _ = ParseTxnTime(txnTime)
+
+	var tests = []struct {
+		name         string
+		now          time.Time
+		respCode     string
+		origRespCode string
+		expired      bool
+		state        TransactionState
+		err          error
+	}{
+		{"before deadline", sent.Add(20 * time.Minute), "", "", false, TransactionStateUnknown, nil},
+		{"within grace period", sent.Add(34 * time.Minute), "", "", false, TransactionStateUnknown, nil},
+		{"not found", sent.Add(time.Hour), "34", "", true, TransactionStateNotFound, nil},
+		{"failed", sent.Add(time.Hour), "00", "30", true, TransactionStateFailed, nil},
+		{"succeeded", sent.Add(time.Hour), "00", "00", false, TransactionStateSucceeded, nil},
+		{"paid after timeout", sent.Add(time.Hour), "00", "42", false, TransactionStatePaidAfterTimeout, nil},
+		{"waiting for input", sent.Add(time.Hour), "00", "70", false, TransactionStatePending, nil},
+		{"debited, settlement unknown", sent.Add(time.Hour), "00", "74", false, TransactionStatePending, nil},
+		{"query failed", sent.Add(time.Hour), "10", "", false, TransactionStateUnknown, Error{Code: CodeFormatError}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var server = newTestGateway(t, pki, testQueryGateway(t, test.respCode, test.origRespCode))
+			var now = test.now
+			var client = newTestClient(t, pki, WithGateway(server.URL), WithClock(ClockFunc(func() time.Time { return now })))
+
+			expiry, err := client.CheckOrderExpiry(context.Background(), "20261019120000abcdef0001", txnTime, 30*time.Minute)
+			if test.err == nil && err != nil || test.err != nil && !errors.Is(err, test.err) {
+				t.Fatalf("CheckOrderExpiry() error = %v, want %v", err, test.err)
+			}
+			if expiry.Expired != test.expired || expiry.State != test.state {
+				t.Fatalf("CheckOrderExpiry() = {Expired: %v, State: %s}, want {Expired: %v, State: %s}", expiry.Expired, expiry.State, test.expired, test.state)
+			}
+			if want := sent.Add(30 * time.Minute); !expiry.Deadline.Equal(want) {
+				t.Fatalf("Deadline = %s, want %s", expiry.Deadline, want)
+			}
+		})
+	}
+}
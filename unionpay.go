@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
@@ -15,10 +16,10 @@ import (
 	"github.com/smartwalle/nsign"
 	"github.com/smartwalle/unionpay/internal"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
-	"strings"
 	"sync"
 	"text/template"
 	"time"
@@ -55,20 +56,50 @@ type Client struct {
 	version    string
 	signMethod string
 
+	// 接入类型
+	accessType  string
+	acqInsCode  string
+	subMerchant SubMerchant
+
+	payTimeout time.Duration
+	clock      Clock
+	orderIds   OrderIdGenerator
+
 	webPaymentTpl *template.Template
 
+	interceptors []Interceptor
+	logger       *slog.Logger
+
+	instrumentation Instrumentation
+	notifications   *notificationTracker
+
+	retryPolicy *RetryPolicy
+
+	// 限流
+	limiter           *limiter
+	throttleListeners []ThrottleFunc
+
 	rootCert  *x509.Certificate
 	interCert *x509.Certificate
 
 	// 签名和验签
-	mu        sync.Mutex
+	signCert  *x509.Certificate
 	signer    Signer
-	verifiers map[string]Verifier
+	verifiers *VerifierCache
+
+	// 证书吊销列表
+	mu         sync.RWMutex
+	crlFetcher CRLFetcher
+	revoked    map[revokedKey]struct{}
 
 	// 敏感信息加密&解密
 	decryptPrivateKey *rsa.PrivateKey
+	encryptMu         sync.RWMutex
 	encryptPublicKey  *rsa.PublicKey
 	encryptCertId     string
+	encryptCert       *x509.Certificate
+	encryptKeyFile    string
+	encryptListeners  []EncryptCertChangeFunc
 }
 
 // New 初始银联客户端
@@ -81,16 +112,11 @@ type Client struct {
 //
 // isProduction - 是否为生产环境，传 false 的时候为沙箱环境，用于开发测试，正式上线的时候需要改为 true
 func New(pfx []byte, password, merchantId string, isProduction bool, opts ...OptionFunc) (*Client, error) {
-	rawKey, certificate, _, err := pkcs12.Decode(pfx, password)
+	privateKey, certificate, err := decodePFX(pfx, password)
 	if err != nil {
 		return nil, err
 	}
 
-	privateKey, _ := rawKey.(*rsa.PrivateKey)
-	if privateKey == nil {
-		return nil, errors.New("key is not a valid *rsa.PrivateKey")
-	}
-
 	var nClient = &Client{}
 	if err = nClient.LoadWebPaymentTemplate(kWebPaymentTemplate); err != nil {
 		return nil, err
@@ -104,12 +130,15 @@ func New(pfx []byte, password, merchantId string, isProduction bool, opts ...Opt
 	}
 	nClient.merchantId = merchantId
 	nClient.certId = certificate.SerialNumber.String()
+	nClient.signCert = certificate
 
 	nClient.version = kVersion
 	nClient.signMethod = kSignMethod
+	nClient.accessType = AccessTypeMerchant
+	nClient.orderIds = NewOrderIdGenerator()
 
 	nClient.signer = nsign.New(nsign.WithMethod(internal.NewRSAMethod(crypto.SHA256, privateKey, nil)))
-	nClient.verifiers = make(map[string]Verifier)
+	nClient.verifiers = NewVerifierCache(kDefaultVerifierCacheSize)
 
 	nClient.decryptPrivateKey = privateKey
 
@@ -122,6 +151,20 @@ func New(pfx []byte, password, merchantId string, isProduction bool, opts ...Opt
 	return nClient, nil
 }
 
+// decodePFX 解析商户私钥证书，返回 RSA 私钥和证书。
+func decodePFX(pfx []byte, password string) (*rsa.PrivateKey, *x509.Certificate, error) {
+	rawKey, certificate, _, err := pkcs12.Decode(pfx, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privateKey, _ := rawKey.(*rsa.PrivateKey)
+	if privateKey == nil {
+		return nil, nil, errors.New("key is not a valid *rsa.PrivateKey")
+	}
+	return privateKey, certificate, nil
+}
+
 // NewWithPFXFile 初始银联客户端
 //
 // filename - 商户私钥证书文件
@@ -131,12 +174,17 @@ func New(pfx []byte, password, merchantId string, isProduction bool, opts ...Opt
 // merchantId - 商户号
 //
 // isProduction - 是否为生产环境，传 false 的时候为沙箱环境，用于开发测试，正式上线的时候需要改为 true
-func NewWithPFXFile(filename, password, merchantId string, isProduction bool) (*Client, error) {
+func NewWithPFXFile(filename, password, merchantId string, isProduction bool, opts ...OptionFunc) (*Client, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	return New(data, password, merchantId, isProduction)
+	return New(data, password, merchantId, isProduction, opts...)
+}
+
+// MerchantId 返回商户号
+func (c *Client) MerchantId() string {
+	return c.merchantId
 }
 
 // LoadWebPaymentTemplate 用于加载跳转银联支付页面的网页模版。
@@ -202,53 +250,6 @@ func (c *Client) LoadIntermediateCertFromFile(filename string) error {
 	return c.loadIntermediateCert(b)
 }
 
-// LoadEncryptKey 银联加密公钥更新查询接口（敏感加密证书）。
-//
-// 商户定期（1天1次）向银联全渠道系统发起获取加密公钥信息交易。在加密公钥证书更新期间，全渠道系统支持新老证书的共同使用，新老证书并行期为1个月。全渠道系统向商户返回最新的加密公钥证书，由商户服务器替换本地证书。
-//
-// 文档地址：https://open.unionpay.com/tjweb/acproduct/APIList?acpAPIId=758&apiservId=448&version=V2.2&bussType=0
-func (c *Client) LoadEncryptKey(ctx context.Context) error {
-	var values = url.Values{}
-	values.Set("accessType", "0")
-	values.Set("channelType", "07") // 渠道类型
-	values.Set("txnType", "95")     // 交易类型 95-银联加密公钥更新查询
-	values.Set("txnSubType", "00")  // 交易子类型 默认00
-	values.Set("bizType", "000000") // 业务类型  默认
-	values.Set("certType", "01")    // 01：敏感信息加密公钥(只有01可用)
-	values.Set("orderId", time.Now().Format("20060102150405"))
-	values.Set("txnTime", time.Now().Format("20060102150405"))
-
-	var rValues, err = c.Request(ctx, kBackTrans, values)
-	if err != nil {
-		return err
-	}
-	var cert = strings.ReplaceAll(rValues.Get("encryptPubKeyCert"), "\r", "\n")
-
-	certificate, err := c.decodeCertificate([]byte(cert))
-	if err != nil {
-		return err
-	}
-	c.encryptPublicKey, _ = certificate.PublicKey.(*rsa.PublicKey)
-	c.encryptCertId = certificate.SerialNumber.String()
-	return nil
-}
-
-// LoadEncryptKeyFromFile 从文件加载银联敏感加密证书。
-func (c *Client) LoadEncryptKeyFromFile(filename string) error {
-	b, err := os.ReadFile(filename)
-	if err != nil {
-		return err
-	}
-
-	certificate, err := c.decodeCertificate(b)
-	if err != nil {
-		return err
-	}
-	c.encryptPublicKey, _ = certificate.PublicKey.(*rsa.PublicKey)
-	c.encryptCertId = certificate.SerialNumber.String()
-	return nil
-}
-
 func (c *Client) decodeCertificate(b []byte) (*x509.Certificate, error) {
 	certificate, err := ncrypto.DecodeCertificate(b)
 	if err != nil {
@@ -268,6 +269,10 @@ func (c *Client) URLValues(values url.Values) (url.Values, error) {
 	values.Set("certId", c.certId)
 	values.Set("signMethod", c.signMethod)
 
+	if err := c.applyAccess(values); err != nil {
+		return nil, err
+	}
+
 	signature, err := c.signer.SignValues(values)
 	if err != nil {
 		return nil, err
@@ -276,39 +281,101 @@ func (c *Client) URLValues(values url.Values) (url.Values, error) {
 	return values, nil
 }
 
-func (c *Client) Request(ct context.Context, api string, values url.Values) (url.Values, error) {
-	values, err := c.URLValues(values)
+// Request 调用银联接口，会对请求参数进行签名，并对应答进行验签。
+//
+// 如果通过 WithRetryPolicy 设置了重试策略，在网络错误或者银联返回 5xx 时会按照重试策略进行处理。
+//
+// 如果通过 WithRateLimit、WithMaxInFlight 设置了限流，会在拦截器之后、发送请求之前等待，等待期间 context 被取消时返回 ErrThrottled。
+func (c *Client) Request(ctx context.Context, api string, values url.Values) (url.Values, error) {
+	var rValues, sent, err = c.request(ctx, api, values)
+	if err == nil || !sent || !isTransient(err) {
+		return rValues, err
+	}
+	if c.retryPolicy != nil && ctx.Err() == nil {
+		return c.retry(ctx, api, values, err)
+	}
+	// 请求已经提交给银联，无法确定银联是否已经受理
+	return nil, c.ambiguous(values, isIdempotent(values), err)
+}
+
+// request 发起一次请求，sent 表示请求是否已经开始提交给银联。
+func (c *Client) request(ctx context.Context, api string, values url.Values) (rValues url.Values, sent bool, err error) {
+	var call = &Call{}
+	call.API = api
+	call.Values = values
+
+	var handler Handler = c.do
+	if c.limiter != nil {
+		handler = c.limit(handler)
+	}
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		var interceptor, next = c.interceptors[i], handler
+		handler = func(ctx context.Context, call *Call) error {
+			return interceptor(ctx, call, next)
+		}
+	}
+
+	if err = handler(ctx, call); err != nil {
+		return nil, call.sent, err
+	}
+	return call.Response, call.sent, nil
+}
+
+func (c *Client) do(ctx context.Context, call *Call) error {
+	values, err := c.URLValues(ngx.CloneValues(call.Values))
 	if err != nil {
-		return nil, err
+		return err
 	}
+	call.Signed = values
 
 	var req = ngx.NewRequest(http.MethodPost, c.host, ngx.WithClient(c.Client))
-	req.JoinPath(api)
+	req.JoinPath(call.API)
 	req.Form = values
 
-	rsp, err := req.Do(ct)
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	var start = time.Now()
+	call.sent = true
+	rsp, err := req.Do(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rsp.Body.Close()
 
 	data, err := io.ReadAll(rsp.Body)
+	call.Latency = time.Since(start)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	call.Body = data
+
+	if rsp.StatusCode != http.StatusOK {
+		return &HTTPError{StatusCode: rsp.StatusCode, Body: data}
 	}
 
 	// 解析返回数据
 	rValues, err := internal.ParseQuery(string(data))
 	if err != nil {
-		return nil, err
+		return err
 	}
+	call.Response = rValues
 
-	// 验证签名
-	if err = c.VerifySign(rValues); err != nil {
-		return nil, err
+	// 银联在报文校验失败（如报文格式错误、certId 错误）时可能返回没有签名的应答
+	if rValues.Get("signature") == "" && rValues.Get("signPubKeyCert") == "" {
+		var code = Code(rValues.Get("respCode"))
+		if code == "" || code.succeeded() {
+			call.VerifyError = ErrUnsignedResponse
+		} else {
+			call.VerifyError = &UnverifiedError{Code: code, Msg: rValues.Get("respMsg")}
+		}
+		return call.VerifyError
 	}
 
-	return rValues, nil
+	// 验证签名
+	call.VerifyError = c.VerifySign(rValues)
+	return call.VerifyError
 }
 
 func (c *Client) VerifySign(values url.Values) error {
@@ -326,26 +393,62 @@ func (c *Client) VerifySign(values url.Values) error {
 }
 
 func (c *Client) getVerifier(cert string) (Verifier, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	der, err := internal.DecodeCertificate([]byte(cert))
+	if err != nil {
+		return nil, err
+	}
 
-	var verifier = c.verifiers[cert]
-	if verifier == nil {
-		certificate, err := ncrypto.DecodeCertificate([]byte(cert))
-		if err != nil {
+	var now = time.Now()
+	var key = c.verifierKey(der)
+	// 缓存可能在多个 Client 之间共享，命中时也需要按照本 Client 加载的吊销列表检查
+	if verifier, certificate := c.verifiers.get(key, now); verifier != nil {
+		if err = c.checkRevoked(certificate); err != nil {
 			return nil, err
 		}
+		return verifier, nil
+	}
 
-		if err = internal.VerifyCert(c.rootCert, c.interCert, certificate); err != nil {
-			return nil, err
-		}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
 
-		verifier = nsign.New(nsign.WithMethod(internal.NewRSAMethod(crypto.SHA256, nil, certificate.PublicKey.(*rsa.PublicKey))))
-		c.verifiers[cert] = verifier
+	if err = internal.VerifyCert(c.rootCert, c.interCert, certificate); err != nil {
+		return nil, err
 	}
+
+	if err = c.checkRevoked(certificate); err != nil {
+		return nil, err
+	}
+
+	var verifier = nsign.New(nsign.WithMethod(internal.NewRSAMethod(crypto.SHA256, nil, certificate.PublicKey.(*rsa.PublicKey))))
+	c.verifiers.add(key, verifier, certificate, now)
 	return verifier, nil
 }
 
+// verifierKey 返回验签缓存的 key，由根证书、中间证书和银联签名公钥证书共同计算，
+// 共享缓存的 Client 加载了不同的根证书或者中间证书时不会命中彼此校验过的证书。
+func (c *Client) verifierKey(der []byte) [32]byte {
+	var h = sha256.New()
+	for _, cert := range []*x509.Certificate{c.rootCert, c.interCert} {
+		var fingerprint [32]byte
+		if cert != nil {
+			fingerprint = sha256.Sum256(cert.Raw)
+		}
+		h.Write(fingerprint[:])
+	}
+	h.Write(der)
+
+	var key [32]byte
+	h.Sum(key[:0])
+	return key
+}
+
+// VerifierCache 返回验签缓存，可用于查看缓存的统计信息。
+func (c *Client) VerifierCache() *VerifierCache {
+	return c.verifiers
+}
+
 // Decrypt 用于解密从银联获取到的敏感信息。
 //
 // 如果商户号开通了【商户对敏感信息加密】的权限，那么需要对获取到的 accNo、pin、phoneNo、cvn2、expired 进行解密。
@@ -370,6 +473,8 @@ func (c *Client) Decrypt(s string) (string, error) {
 //
 // 用于各接口中的 encryptCertId 字段。
 func (c *Client) EncryptCertId() string {
+	c.encryptMu.RLock()
+	defer c.encryptMu.RUnlock()
 	return c.encryptCertId
 }
 
@@ -385,11 +490,16 @@ func (c *Client) Encrypt(s string) (string, error) {
 }
 
 func (c *Client) EncryptBytes(b []byte) (string, error) {
-	if c.encryptPublicKey == nil || c.encryptCertId == "" {
+	c.encryptMu.RLock()
+	var publicKey = c.encryptPublicKey
+	var certId = c.encryptCertId
+	c.encryptMu.RUnlock()
+
+	if publicKey == nil || certId == "" {
 		return "", errors.New("public key not found, you need to call LoadEncryptKey() first")
 	}
 
-	var ciphertext, err = ncrypto.RSAEncrypt(b, c.encryptPublicKey)
+	var ciphertext, err = ncrypto.RSAEncrypt(b, publicKey)
 	if err != nil {
 		return "", err
 	}
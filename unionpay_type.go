@@ -1,6 +1,7 @@
 package unionpay
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 )
@@ -40,10 +41,175 @@ func (c Code) IsFailure() bool {
 	return c != CodeSuccess
 }
 
+// IsPartialSuccess 有缺陷的成功(A6)，交易已经成功，不能当作失败处理。
+//
+// IsSuccess 只对 00 返回 true，A6 同样需要按成功处理时使用本方法判断。
+func (c Code) IsPartialSuccess() bool {
+	return c == CodePartialSuccess
+}
+
+// succeeded 交易成功，包括有缺陷的成功(A6)。
+func (c Code) succeeded() bool {
+	return c.IsSuccess() || c.IsPartialSuccess()
+}
+
+// IsPending 交易状态未明，需要通过交易状态查询接口(GetTransaction)查询交易结果，不能直接当作失败处理。
+//
+// 70（等待持卡人输入）和 74（扣款成功，销账未知）同样需要再次查询。
+func (c Code) IsPending() bool {
+	switch c {
+	case CodeTimeout, CodeUnknownStatus, CodeAccepted, CodeWaitingInput, CodeDebitedUnknown:
+		return true
+	}
+	return false
+}
+
+// IsRetryable 系统暂时不可用或者请求过于频繁，交易未被受理，可以稍后使用相同的参数重新发起请求。
+func (c Code) IsRetryable() bool {
+	switch c {
+	case CodeSystemClosed, CodeSystemBusy, CodeTooFrequent:
+		return true
+	}
+	return false
+}
+
+// IsDuplicate 重复交易，orderId 和 txnTime 组成的订单信息已经存在。
+func (c Code) IsDuplicate() bool {
+	return c == CodeDuplicate
+}
+
+// IsPaidAfterPayTimeout 扣款成功但交易超过规定支付时间(42)，持卡人已经被扣款，商户需要发起退货。
+func (c Code) IsPaidAfterPayTimeout() bool {
+	return c == CodePaidAfterPayTimeout
+}
+
+// IsFinalFailure 交易已经确定失败，使用相同的参数重新发起请求也不会成功。
+//
+// A6（有缺陷的成功）和 42（扣款成功但交易超过规定支付时间）已经扣款，不属于最终失败。
+func (c Code) IsFinalFailure() bool {
+	return !c.succeeded() && !c.IsPending() && !c.IsRetryable() && !c.IsPaidAfterPayTimeout()
+}
+
+// 应答码
+//
+// 文档地址：https://open.unionpay.com/tjweb/support/faq/mchlist?id=234
 const (
-	CodeSuccess Code = "00" // 接口调用成功
+	CodeSuccess        Code = "00" // 成功
+	CodePartialSuccess Code = "A6" // 有缺陷的成功
+
+	CodeFailure       Code = "01" // 交易失败
+	CodeSystemClosed  Code = "02" // 系统未开放或暂时关闭，请稍后再试
+	CodeTimeout       Code = "03" // 交易通讯超时，请发起查询交易
+	CodeUnknownStatus Code = "04" // 交易状态未明，请查询对账结果
+	CodeAccepted      Code = "05" // 交易已受理，请稍后查询交易结果
+	CodeSystemBusy    Code = "06" // 系统繁忙，请稍后再试
+
+	CodeFormatError     Code = "10" // 报文格式错误
+	CodeSignatureError  Code = "11" // 验证签名失败
+	CodeDuplicate       Code = "12" // 重复交易
+	CodeMissingField    Code = "13" // 报文交易要素缺失
+	CodeBatchFileFormat Code = "14" // 批量文件格式错误
+
+	CodeDeclined            Code = "30" // 交易未通过，请尝试使用其他银联卡支付或联系95516
+	CodeMerchantStatus      Code = "31" // 商户状态不正确
+	CodeNoPermission        Code = "32" // 无此交易权限
+	CodeAmountExceeded      Code = "33" // 交易金额超限
+	CodeNotFound            Code = "34" // 查无此交易
+	CodeOrigInvalid         Code = "35" // 原交易不存在或状态不正确
+	CodeOrigMismatch        Code = "36" // 与原交易信息不符
+	CodeTooFrequent         Code = "37" // 已超过最大查询次数或操作过于频繁
+	CodeRiskLimited         Code = "38" // 银联风险受限
+	CodeOutOfServiceTime    Code = "39" // 交易不在受理时间范围内
+	CodeBindFailed          Code = "40" // 绑定关系检查失败
+	CodePaidAfterPayTimeout Code = "42" // 扣款成功但交易超过规定支付时间
+
+	CodeIssuerDeclined      Code = "60" // 交易失败，详情请咨询您的发卡行
+	CodeInvalidCardNo       Code = "61" // 输入的卡号无效，请确认后输入
+	CodeIssuerNotSupported  Code = "62" // 交易失败，发卡银行不支持该商户，请更换其他银行卡支付
+	CodeCardStatus          Code = "63" // 卡状态不正确
+	CodeInsufficientFunds   Code = "64" // 卡上的余额不足
+	CodeInvalidCardInfo     Code = "65" // 输入的密码、有效期或CVN2有误，交易失败
+	CodeInvalidHolderInfo   Code = "66" // 持卡人身份信息或手机号输入不正确，验证失败
+	CodePINRetryExceeded    Code = "67" // 密码输入次数超限
+	CodeCardNotSupported    Code = "68" // 您的银行卡暂不支持该业务，请向您的银行或95516咨询
+	CodeInputTimeout        Code = "69" // 您的输入超时，交易失败
+	CodeWaitingInput        Code = "70" // 交易已跳转，等待持卡人输入
+	CodeSMSCodeInvalid      Code = "71" // 动态口令或短信验证码校验失败
+	CodeCardNotSigned       Code = "72" // 您尚未在银行网点柜面或个人网银签约加办银联无卡支付业务
+	CodeCardExpired         Code = "73" // 支付卡已超过有效期
+	CodeDebitedUnknown      Code = "74" // 扣款成功，销账未知
+	CodeDebitedFailed       Code = "75" // 扣款成功，销账失败
+	CodeSMSRequired         Code = "76" // 需要验证短信
+	CodeCardNotEnabled      Code = "77" // 您的银行卡尚未开通银联在线支付业务
+	CodeIssuerRestricted    Code = "78" // 发卡行交易权限受限，详情请咨询您的发卡行
+	CodeInsufficientBalance Code = "79" // 此卡可用的余额不足
+
+	CodeInternalError Code = "80" // 内部错误
+	CodeSuspicious    Code = "81" // 可疑报文
+	CodeFileNotFound  Code = "98" // 文件不存在
+	CodeGeneralError  Code = "99" // 通用错误
+)
+
+// 与应答码对应的错误信息，可以通过 errors.Is 判断接口返回的错误类型，如：
+//
+// errors.Is(err, unionpay.ErrTransactionNotFound)
+var (
+	ErrPending             = errors.New("transaction status pending, query it later")
+	ErrPaidAfterPayTimeout = errors.New("debited after pay timeout, refund required")
+	ErrSystemUnavailable   = errors.New("system unavailable, retry later")
+	ErrTooFrequent         = errors.New("too many requests")
+	ErrFormat              = errors.New("invalid message format")
+	ErrSignature           = errors.New("signature verification failed")
+	ErrDuplicateOrder      = errors.New("duplicate order")
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrOrigTransaction     = errors.New("original transaction not found or mismatch")
+	ErrPermissionDenied    = errors.New("permission denied")
+	ErrAmountExceeded      = errors.New("amount exceeded")
+	ErrRiskControl         = errors.New("rejected by risk control")
+	ErrInsufficientFunds   = errors.New("insufficient funds")
+	ErrInvalidCard         = errors.New("invalid card")
+	ErrCardholderAuth      = errors.New("cardholder authentication failed")
 )
 
+var codeErrors = map[Code]error{
+	CodeSystemClosed:        ErrSystemUnavailable,
+	CodeTimeout:             ErrPending,
+	CodeUnknownStatus:       ErrPending,
+	CodeAccepted:            ErrPending,
+	CodeSystemBusy:          ErrSystemUnavailable,
+	CodeFormatError:         ErrFormat,
+	CodeSignatureError:      ErrSignature,
+	CodeDuplicate:           ErrDuplicateOrder,
+	CodeMissingField:        ErrFormat,
+	CodeBatchFileFormat:     ErrFormat,
+	CodeMerchantStatus:      ErrPermissionDenied,
+	CodeNoPermission:        ErrPermissionDenied,
+	CodeAmountExceeded:      ErrAmountExceeded,
+	CodeNotFound:            ErrTransactionNotFound,
+	CodeOrigInvalid:         ErrOrigTransaction,
+	CodeOrigMismatch:        ErrOrigTransaction,
+	CodeTooFrequent:         ErrTooFrequent,
+	CodeRiskLimited:         ErrRiskControl,
+	CodePaidAfterPayTimeout: ErrPaidAfterPayTimeout,
+	CodeInvalidCardNo:       ErrInvalidCard,
+	CodeCardStatus:          ErrInvalidCard,
+	CodeCardExpired:         ErrInvalidCard,
+	CodeInsufficientFunds:   ErrInsufficientFunds,
+	CodeInsufficientBalance: ErrInsufficientFunds,
+	CodeInvalidCardInfo:     ErrCardholderAuth,
+	CodeInvalidHolderInfo:   ErrCardholderAuth,
+	CodePINRetryExceeded:    ErrCardholderAuth,
+	CodeSMSCodeInvalid:      ErrCardholderAuth,
+	CodeWaitingInput:        ErrPending,
+	CodeDebitedUnknown:      ErrPending,
+}
+
+// Error 银联接口的应答信息。
+//
+// 各接口的结果中包含 Error，应答码(respCode)表示失败时依然返回结果和 nil，需要通过结果中的 Error 判断交易是否成功；
+// 只有网络错误、签名验证失败等没有得到有效应答的情况才会返回 error。
+//
+// 可以使用 IsPending、IsRetryable 等方法对应答码进行分类，也可以使用 errors.Is 将 Error 与 ErrTransactionNotFound 等错误信息进行比较。
 type Error struct {
 	Code Code   `query:"respCode"`
 	Msg  string `query:"respMsg"`
@@ -53,6 +219,17 @@ func (e Error) Error() string {
 	return fmt.Sprintf("%s - %s", e.Code, e.Msg)
 }
 
+// Is 用于支持 errors.Is，target 可以是 ErrTransactionNotFound 等错误信息，也可以是 Error（比较 Code）。
+func (e Error) Is(target error) bool {
+	switch t := target.(type) {
+	case Error:
+		return e.Code == t.Code
+	case *Error:
+		return t != nil && e.Code == t.Code
+	}
+	return codeErrors[e.Code] == target && target != nil
+}
+
 func (e Error) IsSuccess() bool {
 	return e.Code.IsSuccess()
 }
@@ -61,6 +238,59 @@ func (e Error) IsFailure() bool {
 	return e.Code.IsFailure()
 }
 
+func (e Error) IsPending() bool {
+	return e.Code.IsPending()
+}
+
+func (e Error) IsRetryable() bool {
+	return e.Code.IsRetryable()
+}
+
+func (e Error) IsDuplicate() bool {
+	return e.Code.IsDuplicate()
+}
+
+func (e Error) IsPaidAfterPayTimeout() bool {
+	return e.Code.IsPaidAfterPayTimeout()
+}
+
+func (e Error) IsPartialSuccess() bool {
+	return e.Code.IsPartialSuccess()
+}
+
+func (e Error) IsFinalFailure() bool {
+	return e.Code.IsFinalFailure()
+}
+
+// err 在应答码表示失败时返回 Error 本身，否则（包括有缺陷的成功）返回 nil。
+func (e Error) err() error {
+	if !e.Code.succeeded() {
+		return e
+	}
+	return nil
+}
+
+// ErrUnsignedResponse 银联返回了没有签名的成功应答，由于无法验证其真实性，该应答会被拒绝。
+var ErrUnsignedResponse = errors.New("unsigned response")
+
+// UnverifiedError 银联返回的没有签名的错误应答。
+//
+// 银联在报文校验失败（如报文格式错误、certId 错误）时可能返回没有签名的应答，该应答的内容没有经过验签，只能用于排查问题，不能作为交易结果的依据。
+//
+// UnverifiedError 支持使用 errors.Is 与 ErrFormat 等错误信息进行比较，但是不能通过 errors.As 转换为 Error。
+type UnverifiedError struct {
+	Code Code
+	Msg  string
+}
+
+func (e *UnverifiedError) Error() string {
+	return fmt.Sprintf("unverified response: %s - %s", e.Code, e.Msg)
+}
+
+func (e *UnverifiedError) Is(target error) bool {
+	return Error{Code: e.Code, Msg: e.Msg}.Is(target)
+}
+
 type Payload struct {
 	values url.Values
 }
@@ -80,6 +310,7 @@ func (p *Payload) AddParam(key, value string) *Payload {
 
 type CallOption func(values url.Values)
 
+// WithPayload 设置额外的请求参数，请求结构体中非空的字段会替换同名参数，替换之后的请求参数同样会经过校验。
 func WithPayload(payload *Payload) CallOption {
 	return func(values url.Values) {
 		if payload != nil {
@@ -0,0 +1,170 @@
+package unionpay
+
+import (
+	"crypto/x509"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const kDefaultVerifierCacheSize = 32
+
+// VerifierCacheStats 验签缓存的统计信息。
+type VerifierCacheStats struct {
+	Hits        uint64 // 命中次数
+	Misses      uint64 // 未命中次数
+	Evictions   uint64 // 因容量不足被淘汰的次数
+	Expirations uint64 // 因证书过期被移除的次数
+	Size        int    // 当前缓存数量
+	Capacity    int    // 最大缓存数量
+}
+
+// VerifierCache 用于缓存银联签名公钥证书(signPubKeyCert)对应的 Verifier。
+//
+// 缓存以根证书、中间证书和银联签名公钥证书共同计算的 SHA-256 为 key，超过容量时淘汰最久未使用的证书，证书到达 NotAfter 之后自动失效。
+//
+// 同一个 VerifierCache 可以通过 WithVerifierCache 在多个 Client 之间共享，加载了不同根证书或者中间证书的 Client 不会命中彼此校验过的证书，
+// 命中缓存时也会按照各自加载的吊销列表进行检查。
+type VerifierCache struct {
+	capacity int
+
+	mu      sync.RWMutex
+	entries map[[32]byte]*verifierEntry
+
+	tick        atomic.Uint64
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+type verifierEntry struct {
+	verifier Verifier
+	cert     *x509.Certificate
+	lastUsed atomic.Uint64
+}
+
+// NewVerifierCache 创建验签缓存，capacity 为最大缓存数量，小于等于 0 时为 32。
+func NewVerifierCache(capacity int) *VerifierCache {
+	if capacity <= 0 {
+		capacity = kDefaultVerifierCacheSize
+	}
+	var nCache = &VerifierCache{}
+	nCache.capacity = capacity
+	nCache.entries = make(map[[32]byte]*verifierEntry)
+	return nCache
+}
+
+// WithVerifierCache 设置验签缓存，可用于在多个 Client 之间共享。
+func WithVerifierCache(cache *VerifierCache) OptionFunc {
+	return func(c *Client) {
+		if cache != nil {
+			c.verifiers = cache
+		}
+	}
+}
+
+func (vc *VerifierCache) get(key [32]byte, now time.Time) (Verifier, *x509.Certificate) {
+	vc.mu.RLock()
+	var entry = vc.entries[key]
+	vc.mu.RUnlock()
+
+	if entry == nil {
+		vc.misses.Add(1)
+		return nil, nil
+	}
+
+	if !now.Before(entry.cert.NotAfter) {
+		vc.mu.Lock()
+		if vc.entries[key] == entry {
+			delete(vc.entries, key)
+			vc.expirations.Add(1)
+		}
+		vc.mu.Unlock()
+		vc.misses.Add(1)
+		return nil, nil
+	}
+
+	entry.lastUsed.Store(vc.tick.Add(1))
+	vc.hits.Add(1)
+	return entry.verifier, entry.cert
+}
+
+func (vc *VerifierCache) add(key [32]byte, verifier Verifier, cert *x509.Certificate, now time.Time) {
+	var entry = &verifierEntry{}
+	entry.verifier = verifier
+	entry.cert = cert
+	entry.lastUsed.Store(vc.tick.Add(1))
+
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	if _, ok := vc.entries[key]; !ok && len(vc.entries) >= vc.capacity {
+		// 优先移除已过期的证书，没有过期证书时淘汰最久未使用的证书
+		for k, item := range vc.entries {
+			if !now.Before(item.cert.NotAfter) {
+				delete(vc.entries, k)
+				vc.expirations.Add(1)
+			}
+		}
+
+		if len(vc.entries) >= vc.capacity {
+			var oldestKey [32]byte
+			var oldest uint64
+			var found = false
+			for k, item := range vc.entries {
+				if lastUsed := item.lastUsed.Load(); !found || lastUsed < oldest {
+					oldestKey = k
+					oldest = lastUsed
+					found = true
+				}
+			}
+			delete(vc.entries, oldestKey)
+			vc.evictions.Add(1)
+		}
+	}
+	vc.entries[key] = entry
+}
+
+func (vc *VerifierCache) removeFunc(fn func(cert *x509.Certificate) bool) {
+	vc.mu.Lock()
+	for key, entry := range vc.entries {
+		if fn(entry.cert) {
+			delete(vc.entries, key)
+		}
+	}
+	vc.mu.Unlock()
+}
+
+func (vc *VerifierCache) certificates() []*x509.Certificate {
+	vc.mu.RLock()
+	var certs = make([]*x509.Certificate, 0, len(vc.entries))
+	for _, entry := range vc.entries {
+		certs = append(certs, entry.cert)
+	}
+	vc.mu.RUnlock()
+	return certs
+}
+
+// Purge 清空缓存。
+func (vc *VerifierCache) Purge() {
+	vc.mu.Lock()
+	vc.entries = make(map[[32]byte]*verifierEntry)
+	vc.mu.Unlock()
+}
+
+// Stats 返回缓存的统计信息。
+func (vc *VerifierCache) Stats() VerifierCacheStats {
+	vc.mu.RLock()
+	var size = len(vc.entries)
+	vc.mu.RUnlock()
+
+	var stats = VerifierCacheStats{}
+	stats.Hits = vc.hits.Load()
+	stats.Misses = vc.misses.Load()
+	stats.Evictions = vc.evictions.Load()
+	stats.Expirations = vc.expirations.Load()
+	stats.Size = size
+	stats.Capacity = vc.capacity
+	return stats
+}
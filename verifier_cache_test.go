@@ -0,0 +1,213 @@
+package unionpay
+
+import (
+	"crypto/x509"
+	"github.com/smartwalle/nsign"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testVerifier string
+
+func (v testVerifier) VerifyValues(values url.Values, signature []byte, opts ...nsign.SignOption) error {
+	return nil
+}
+
+func (v testVerifier) VerifyBytes(data []byte, signature []byte, opts ...nsign.SignOption) error {
+	return nil
+}
+
+func testFingerprint(b byte) [32]byte {
+	return [32]byte{b}
+}
+
+func TestVerifierCache(t *testing.T) {
+	var now = time.Date(2026, 10, 19, 12, 0, 0, 0, kBeijing)
+	var valid = &x509.Certificate{NotAfter: now.Add(time.Hour)}
+
+	type step struct {
+		op   string // add、get
+		key  byte
+		cert *x509.Certificate
+		at   time.Duration // 相对 now 的时间
+		hit  bool
+	}
+
+	var tests = []struct {
+		name     string
+		capacity int
+		steps    []step
+		want     VerifierCacheStats
+	}{
+		{
+			name:     "hit and miss",
+			capacity: 2,
+			steps: []step{
+				{op: "get", key: 1},
+				{op: "add", key: 1, cert: valid},
+				{op: "get", key: 1, hit: true},
+			},
+			want: VerifierCacheStats{Hits: 1, Misses: 1, Size: 1, Capacity: 2},
+		},
+		{
+			name:     "evict least recently used",
+			capacity: 2,
+			steps: []step{
+				{op: "add", key: 1, cert: valid},
+				{op: "add", key: 2, cert: valid},
+				{op: "get", key: 1, hit: true},
+				{op: "add", key: 3, cert: valid},
+				{op: "get", key: 2},
+				{op: "get", key: 1, hit: true},
+				{op: "get", key: 3, hit: true},
+			},
+			want: VerifierCacheStats{Hits: 3, Misses: 1, Evictions: 1, Size: 2, Capacity: 2},
+		},
+		{
+			name:     "re-adding an entry does not evict",
+			capacity: 2,
+			steps: []step{
+				{op: "add", key: 1, cert: valid},
+				{op: "add", key: 2, cert: valid},
+				{op: "add", key: 2, cert: valid},
+				{op: "get", key: 1, hit: true},
+			},
+			want: VerifierCacheStats{Hits: 1, Size: 2, Capacity: 2},
+		},
+		{
+			name:     "expire at NotAfter",
+			capacity: 2,
+			steps: []step{
+				{op: "add", key: 1, cert: valid},
+				{op: "get", key: 1, at: time.Hour - time.Second, hit: true},
+				{op: "get", key: 1, at: time.Hour},
+			},
+			want: VerifierCacheStats{Hits: 1, Misses: 1, Expirations: 1, Size: 0, Capacity: 2},
+		},
+		{
+			name:     "expired entries are removed before evicting",
+			capacity: 2,
+			steps: []step{
+				{op: "add", key: 1, cert: &x509.Certificate{NotAfter: now.Add(time.Minute)}},
+				{op: "add", key: 2, cert: valid},
+				{op: "get", key: 2, hit: true},
+				{op: "add", key: 3, cert: valid, at: 2 * time.Minute},
+				{op: "get", key: 2, at: 2 * time.Minute, hit: true},
+			},
+			want: VerifierCacheStats{Hits: 2, Expirations: 1, Size: 2, Capacity: 2},
+		},
+		{
+			name:     "default capacity",
+			capacity: 0,
+			steps:    []step{},
+			want:     VerifierCacheStats{Capacity: kDefaultVerifierCacheSize},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var cache = NewVerifierCache(test.capacity)
+			for i, s := range test.steps {
+				switch s.op {
+				case "add":
+					cache.add(testFingerprint(s.key), testVerifier(strconv.Itoa(int(s.key))), s.cert, now.Add(s.at))
+				case "get":
+					verifier, _ := cache.get(testFingerprint(s.key), now.Add(s.at))
+					if hit := verifier != nil; hit != s.hit {
+						t.Fatalf("step %d: get(%d) hit = %v, want %v", i, s.key, hit, s.hit)
+					}
+					if s.hit && verifier != testVerifier(strconv.Itoa(int(s.key))) {
+						t.Fatalf("step %d: get(%d) returned verifier %v", i, s.key, verifier)
+					}
+				}
+			}
+			if stats := cache.Stats(); stats != test.want {
+				t.Fatalf("Stats() = %+v, want %+v", stats, test.want)
+			}
+		})
+	}
+}
+
+func TestVerifierCache_Purge(t *testing.T) {
+	var now = time.Now()
+	var cache = NewVerifierCache(4)
+	cache.add(testFingerprint(1), testVerifier("1"), &x509.Certificate{NotAfter: now.Add(time.Hour)}, now)
+	cache.Purge()
+	if verifier, _ := cache.get(testFingerprint(1), now); verifier != nil {
+		t.Fatal("get() returned a verifier after Purge()")
+	}
+}
+
+func TestVerifierCache_Concurrent(t *testing.T) {
+	var now = time.Now()
+	var cache = NewVerifierCache(8)
+	var cert = &x509.Certificate{NotAfter: now.Add(time.Hour)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				var key = testFingerprint(byte((i + j) % 16))
+				if verifier, _ := cache.get(key, now); verifier == nil {
+					cache.add(key, testVerifier("v"), cert, now)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if stats := cache.Stats(); stats.Size > stats.Capacity {
+		t.Fatalf("cache size %d exceeds capacity %d", stats.Size, stats.Capacity)
+	}
+}
+
+// 同一张证书只在第一次验签时校验证书链，之后命中缓存。
+func TestClient_VerifySignUsesCache(t *testing.T) {
+	var pki = newTestPKI(t)
+	var client = newTestClient(t, pki)
+
+	for i := 0; i < 3; i++ {
+		if err := client.VerifySign(testSignedValues(t, pki, "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stats = client.VerifierCache().Stats()
+	if stats.Misses != 1 || stats.Hits != 2 || stats.Size != 1 {
+		t.Fatalf("Stats() = %+v, want 1 miss, 2 hits and 1 entry", stats)
+	}
+}
+
+// 共享验签缓存时，证书只对加载了相同根证书和中间证书的 Client 命中缓存。
+func TestClient_SharedCacheTrustAnchors(t *testing.T) {
+	var pki = newTestPKI(t)
+	var other = newTestPKI(t)
+	var cache = NewVerifierCache(0)
+	var trusted = newTestClient(t, pki, WithVerifierCache(cache))
+	var sameAnchors = newTestClient(t, pki, WithVerifierCache(cache))
+	var otherAnchors = newTestClient(t, other, WithVerifierCache(cache))
+
+	if err := trusted.VerifySign(testSignedValues(t, pki, "")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sameAnchors.VerifySign(testSignedValues(t, pki, "")); err != nil {
+		t.Fatal(err)
+	}
+	if hits := cache.Stats().Hits; hits != 1 {
+		t.Fatalf("cache hits = %d, want 1", hits)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := otherAnchors.VerifySign(testSignedValues(t, pki, "")); err == nil {
+			t.Fatal("VerifySign() accepted a certificate issued by an untrusted root")
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Size != 1 {
+		t.Fatalf("Stats() = %+v, want 1 hit and 1 entry", stats)
+	}
+}